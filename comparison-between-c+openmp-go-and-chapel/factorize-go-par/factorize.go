@@ -143,75 +143,90 @@ func sieveInterval(n *big.Int) (*big.Int, *big.Int) {
 	return sieveMin, sieveMax
 }
 
-func sieveWork(n *big.Int, factorBase []*big.Int, cMin, cMax *big.Int, retCis, retDs []*big.Int, retExponents [][]int, done chan<- bool) {
+/* c(i) per segment. the workers only ever hold one segment, memory use doesn't grow with the interval */
+const segmentSize = 1 << 16
 
-	retIterator := 0
+type relation struct {
+	ci, d     *big.Int
+	exponents []int
+}
+
+/* sieves the segments [start, min(start + segmentSize, cMax + 1)) from the channel and sends each relation
+as soon as it is found */
+func sieveWork(n *big.Int, factorBase []*big.Int, cMax *big.Int, segments <-chan *big.Int, relations chan<- relation, done chan<- bool) {
+
+	segmentSizeBig := big.NewInt(segmentSize)
+	end := big.NewInt(0)
 
-	ci := big.NewInt(0)
-	ci.Set(cMin)
 	d := big.NewInt(0)
 	rest := big.NewInt(0)
 	tmpRest := big.NewInt(0)
 	tmpQuotient := big.NewInt(0)
 	exponents := make([]int, len(factorBase))
 
-	/* foreach c(i) */
-	for ; ci.Cmp(cMax) == -1; ci.Add(ci, one) {
+	for start := range segments {
 
-		/* calculate c(i)^1 - n */
-		d.Mul(ci, ci)
-		d.Sub(d, n)
+		end.Add(start, segmentSizeBig)
+		if end.Cmp(cMax) == 1 {
+			end.Add(cMax, one)
+		}
 
-		/* copy the result because we need to modify it and save c(i)^2 - n as well */
-		rest.Set(d)
+		/* foreach c(i) */
+		for ci := start; ci.Cmp(end) == -1; ci.Add(ci, one) {
 
-		for i, p := range factorBase {
+			/* calculate c(i)^1 - n */
+			d.Mul(ci, ci)
+			d.Sub(d, n)
 
-			exponents[i] = 0
+			/* copy the result because we need to modify it and save c(i)^2 - n as well */
+			rest.Set(d)
 
-			repeat := true
+			for i, p := range factorBase {
 
-			/* repeat as long as rest % p == 0 -> add 1 to the exponent for each division by p */
-			for repeat == true {
+				exponents[i] = 0
 
-				if i == 0 {
-					/* needs special handling: p = -1 */
-					if rest.Sign() == -1 {
-						exponents[0] = 1
-						rest.Mul(rest, minusOne)
-					} else {
-						exponents[0] = 0
+				repeat := true
+
+				/* repeat as long as rest % p == 0 -> add 1 to the exponent for each division by p */
+				for repeat == true {
+
+					if i == 0 {
+						/* needs special handling: p = -1 */
+						if rest.Sign() == -1 {
+							exponents[0] = 1
+							rest.Mul(rest, minusOne)
+						} else {
+							exponents[0] = 0
+						}
+						repeat = false
+						continue
 					}
-					repeat = false
-					continue
-				}
 
-				tmpQuotient.DivMod(rest, p, tmpRest)
+					tmpQuotient.DivMod(rest, p, tmpRest)
 
-				if tmpRest.Cmp(zero) == 0 {
-					exponents[i] += 1
-					rest.Set(tmpQuotient)
-				} else {
-					repeat = false
-				}
+					if tmpRest.Cmp(zero) == 0 {
+						exponents[i] += 1
+						rest.Set(tmpQuotient)
+					} else {
+						repeat = false
+					}
 
-				if tmpQuotient.Cmp(zero) == 0 {
-					repeat = false
-				}
+					if tmpQuotient.Cmp(zero) == 0 {
+						repeat = false
+					}
 
+				}
 			}
-		}
 
-		/* if rest is 1 c(i)^2 - n has been successfully broken down into a number that can be represented through
-		the factor base -> save c(i)^2 and the exponents for the prime factors */
-		if rest.Cmp(one) == 0 {
-			retDs[retIterator] = big.NewInt(0)
-			retDs[retIterator].Set(d)
-			retCis[retIterator] = big.NewInt(0)
-			retCis[retIterator].Set(ci)
-			retExponents[retIterator] = make([]int, len(factorBase))
-			copy(retExponents[retIterator], exponents)
-			retIterator += 1
+			/* if rest is 1 c(i)^2 - n has been successfully broken down into a number that can be represented through
+			the factor base -> send c(i) and c(i)^2 - n and the exponents for the prime factors */
+			if rest.Cmp(one) == 0 {
+				r := relation{big.NewInt(0), big.NewInt(0), make([]int, len(factorBase))}
+				r.ci.Set(ci)
+				r.d.Set(d)
+				copy(r.exponents, exponents)
+				relations <- r
+			}
 		}
 	}
 
@@ -220,91 +235,37 @@ func sieveWork(n *big.Int, factorBase []*big.Int, cMin, cMax *big.Int, retCis, r
 
 func sieve(n *big.Int, factorBase []*big.Int, cMin, cMax *big.Int) ([]*big.Int, []*big.Int, [][]int) {
 
-	intervalBig := big.NewInt(0)
-	intervalBig.Sub(cMax, cMin)
-	intervalBig.Add(intervalBig, one)
-
-	if intervalBig.BitLen() > 31 {
-		panic("fufufu sieve interval too large. code newly.")
-	}
-
-	interval := int(intervalBig.Int64())
-
-	retCiTmp := make([]*big.Int, interval)
-	retDsTmp := make([]*big.Int, interval)
-	retExponentsTmp := make([][]int, interval)
-
 	threads := runtime.GOMAXPROCS(-1)
-	tasksRest := interval % threads
-	tasksStep := interval / threads
-
-	tasksStepBig := big.NewInt(int64(tasksStep))
-	tasksStepBigPlusOne := big.NewInt(int64(tasksStep + 1))
-
-	ci := big.NewInt(0)
-	ci.Set(cMin)
 
+	segments := make(chan *big.Int, threads)
+	relations := make(chan relation) /* unbuffered, every relation is received before its worker reports done */
 	doneChannel := make(chan bool)
 
-	/* foreach c(i) */
-	for i := 0; ci.Cmp(cMax) <= 0; {
-
-		cMinIt := big.NewInt(0)
-		cMaxIt := big.NewInt(0)
-		cMinIt.Set(ci)
-
-		j := 0
-
-		if tasksRest > 0 {
-			cMaxIt.Add(cMinIt, tasksStepBigPlusOne)
-			j = i + tasksStep + 1
-		} else {
-			cMaxIt.Add(cMinIt, tasksStepBig)
-			j = i + tasksStep
-		}
-
-		go sieveWork(n, factorBase, cMinIt, cMaxIt, retCiTmp[i:j], retDsTmp[i:j], retExponentsTmp[i:j], doneChannel)
-
-		if tasksRest > 0 {
-			ci.Add(ci, tasksStepBigPlusOne)
-			tasksRest -= 1
-			i += tasksStep + 1
-		} else {
-			ci.Add(ci, tasksStepBig)
-			i += tasksStep
-		}
-	}
-
-	max := threads
-	if tasksStep == 0 {
-		max = tasksRest
-	}
-
-	for i := 0; i < max; i += 1 {
-		<-doneChannel
+	for i := 0; i < threads; i += 1 {
+		go sieveWork(n, factorBase, cMax, segments, relations, doneChannel)
 	}
 
-	retIterator := 0
-	for _, ci := range retCiTmp {
-		if ci != nil {
-			retIterator += 1
+	/* hand out the segments in order */
+	go func() {
+		segmentSizeBig := big.NewInt(segmentSize)
+		for start := big.NewInt(0).Set(cMin); start.Cmp(cMax) <= 0; start.Add(start, segmentSizeBig) {
+			segments <- big.NewInt(0).Set(start)
 		}
-	}
-
-	/* copy the result into new, shorter arrays */
-	retDs := make([]*big.Int, retIterator)
-	retCi := make([]*big.Int, retIterator)
-	retExponents := make([][]int, retIterator)
-
-	i := 0
-	j := 0
-	for ; j < interval; j += 1 {
-
-		if retCiTmp[j] != nil {
-			retDs[i] = retDsTmp[j] // c(i)^2 - n
-			retCi[i] = retCiTmp[j] // c(i)
-			retExponents[i] = retExponentsTmp[j]
-			i += 1
+		close(segments)
+	}()
+
+	retDs := make([]*big.Int, 0)
+	retCi := make([]*big.Int, 0)
+	retExponents := make([][]int, 0)
+
+	for running := threads; running > 0; {
+		select {
+		case r := <-relations:
+			retDs = append(retDs, r.d) // c(i)^2 - n
+			retCi = append(retCi, r.ci) // c(i)
+			retExponents = append(retExponents, r.exponents)
+		case <-doneChannel:
+			running -= 1
 		}
 	}
 
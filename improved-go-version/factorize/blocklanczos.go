@@ -0,0 +1,441 @@
+package main
+
+/* montgomery's block lanczos over GF(2).
+ *
+ * B is the sparse relation matrix (rows: factor base primes, columns: relations) and A = B^T B is
+ * symmetric. starting from V_0 = A Y for a random n x 64 block Y the iteration builds A-orthogonal
+ * blocks V_i and X = sum V_i W_i^-1 V_i^T V_0 with A X = A Y, until V_m^T A V_m = 0. combinations of
+ * the columns of X - Y and V_m that B maps to zero are dependencies between the relations.
+ *
+ * n-vectors of blocks are []uint64, bit j of v[k] is row k of column j. */
+
+import (
+	"math/bits"
+	"math/rand"
+)
+
+
+/* 64 x 64 matrix over GF(2). row i is the i-th uint64, column j bit j */
+type blockMatrix [64]uint64
+
+
+/* how often block lanczos is restarted with another random block before giving up */
+const lanczosAttempts = 3
+
+
+/* the dependencies between the relations (index sets), nil if block lanczos failed */
+func blockLanczosDependencies(exponents [][]int) [][]int {
+
+	b := sparseMatrixFromExponents(exponents)
+
+	for seed := int64(1); seed <= lanczosAttempts; seed += 1 {
+		if dependencies := blockLanczos(b, seed); len(dependencies) > 0 {
+			return dependencies
+		}
+	}
+
+	return nil
+}
+
+
+/* up to 64 independent dependencies between the columns of B, nil if the iteration broke down */
+func blockLanczos(b *SparseMatrix, seed int64) [][]int {
+
+	n := b.columnCount
+
+	rng := rand.New(rand.NewSource(seed))
+
+	mulA := func(v []uint64) []uint64 {
+		return b.MulTransposed(b.Mul(v))
+	}
+
+	y := make([]uint64, n)
+	for k := range y {
+		y[k] = rng.Uint64()
+	}
+
+	vInit := mulA(y)
+
+	x := make([]uint64, n)
+
+	/* V_i, V_i-1, V_i-2 and what is kept of the last two iterations */
+	v0 := make([]uint64, n)
+	copy(v0, vInit)
+	v1 := make([]uint64, n)
+	v2 := make([]uint64, n)
+
+	var vtav1, vta2v1, winv1, winv2 blockMatrix
+	s1 := ^uint64(0)
+
+	identity := identityBlock()
+
+	converged := false
+
+	/* every iteration gains almost 64 dimensions */
+	for iteration := 0; iteration < n / 60 + 100; iteration += 1 {
+
+		av := mulA(v0)
+
+		vtav0 := innerProductBlock(v0, av)
+		vta2v0 := innerProductBlock(av, av)
+
+		if vtav0 == (blockMatrix{}) {
+			converged = true
+			break
+		}
+
+		winv0, s0, ok := lanczosNonsingular(vtav0, s1)
+		if ok == false {
+			return nil
+		}
+
+		/* X += V_i W_i^-1 V_i^T V_0 */
+		xUpdate := mulVectorBlock(v0, mulBlock(winv0, innerProductBlock(v0, vInit)))
+		for k := range x {
+			x[k] ^= xUpdate[k]
+		}
+
+		/* D = I - W_i^-1 (V_i^T A^2 V_i S_i S_i^T + V_i^T A V_i) */
+		d := mulBlock(winv0, addBlock(maskColumnsBlock(vta2v0, s0), vtav0))
+		d = addBlock(d, identity)
+
+		/* E = - W_i-1^-1 V_i^T A V_i S_i S_i^T */
+		e := mulBlock(winv1, maskColumnsBlock(vtav0, s0))
+
+		/* F = - W_i-2^-1 (I - V_i-1^T A V_i-1 W_i-1^-1) (V_i-1^T A^2 V_i-1 S_i-1 S_i-1^T + V_i-1^T A V_i-1) S_i S_i^T */
+		f := mulBlock(addBlock(identity, mulBlock(vtav1, winv1)), addBlock(maskColumnsBlock(vta2v1, s1), vtav1))
+		f = maskColumnsBlock(mulBlock(winv2, f), s0)
+
+		vd := mulVectorBlock(v0, d)
+		ve := mulVectorBlock(v1, e)
+		vf := mulVectorBlock(v2, f)
+
+		/* V_i+1 = A V_i S_i S_i^T + V_i D + V_i-1 E + V_i-2 F */
+		for k := range av {
+			av[k] = (av[k] & s0) ^ vd[k] ^ ve[k] ^ vf[k]
+		}
+
+		v0, v1, v2 = av, v0, v1
+		vtav1, vta2v1 = vtav0, vta2v0
+		winv0, winv1, winv2 = blockMatrix{}, winv0, winv1
+		s1 = s0
+	}
+
+	if converged == false {
+		return nil
+	}
+
+	for k := range x {
+		x[k] ^= y[k]
+	}
+
+	return nullspaceFromBlocks(b, [][]uint64{x, v0})
+}
+
+
+/* combines the columns of the blocks to vectors B maps to zero. returns the independent ones as index sets */
+func nullspaceFromBlocks(b *SparseMatrix, blocks [][]uint64) [][]int {
+
+	n := b.columnCount
+
+	/* the columns of B [block_0 | block_1 | ...] as rows */
+	images := []*Row{}
+	for _, block := range blocks {
+		image := b.Mul(block)
+		for j := 0; j < 64; j += 1 {
+			images = append(images, blockColumnToRow(image, j))
+		}
+	}
+
+	_, combinations := eliminate(images)
+
+	vectors := []*Row{}
+
+	for _, combination := range combinations {
+
+		/* the selected columns per block */
+		selected := make([]uint64, len(blocks))
+		for i := range images {
+			selected[i / 64] |= uint64(combination.Column(i)) << uint(i % 64)
+		}
+
+		vector := NewRow(n)
+		for k := 0; k < n; k += 1 {
+			parity := 0
+			for i, block := range blocks {
+				parity += bits.OnesCount64(block[k] & selected[i])
+			}
+			vector.SetColumn(k, Bit(parity % 2))
+		}
+
+		if vector.IsZero() == false {
+			vectors = append(vectors, vector)
+		}
+	}
+
+	/* eliminate() modifies its input */
+	copies := make([]*Row, len(vectors))
+	for i, vector := range vectors {
+		copies[i] = NewRow(n)
+		copies[i].Set(vector)
+	}
+
+	independent, _ := eliminate(copies)
+
+	ret := [][]int{}
+
+	for _, i := range independent {
+
+		if len(ret) == 64 {
+			break
+		}
+
+		indexSet := []int{}
+		for k := 0; k < n; k += 1 {
+			if vectors[i].Column(k) == 1 {
+				indexSet = append(indexSet, k)
+			}
+		}
+
+		ret = append(ret, indexSet)
+	}
+
+	return ret
+}
+
+
+/* W^-1 = (S^T T S)^-1 padded with zeros and the column selection S (a bit mask). columns not in
+sPrevious are chosen first, all of them have to be in S. false if T has no suitable submatrix */
+func lanczosNonsingular(t blockMatrix, sPrevious uint64) (blockMatrix, uint64, bool) {
+
+	/* [T | I] */
+	left := t
+	right := identityBlock()
+
+	order := make([]int, 0, 64)
+	for c := 0; c < 64; c += 1 {
+		if (sPrevious >> uint(c)) & 1 == 0 {
+			order = append(order, c)
+		}
+	}
+	for c := 0; c < 64; c += 1 {
+		if (sPrevious >> uint(c)) & 1 == 1 {
+			order = append(order, c)
+		}
+	}
+
+	swap := func(a, b int) {
+		left[a], left[b] = left[b], left[a]
+		right[a], right[b] = right[b], right[a]
+	}
+
+	s := uint64(0)
+
+	for j, c := range order {
+
+		for k := j; k < 64; k += 1 {
+			if (left[order[k]] >> uint(c)) & 1 == 1 {
+				swap(c, order[k])
+				break
+			}
+		}
+
+		if (left[c] >> uint(c)) & 1 == 1 {
+
+			s |= 1 << uint(c)
+
+			for k := 0; k < 64; k += 1 {
+				if k != c && (left[k] >> uint(c)) & 1 == 1 {
+					left[k] ^= left[c]
+					right[k] ^= right[c]
+				}
+			}
+
+		} else {
+
+			for k := j; k < 64; k += 1 {
+				if (right[order[k]] >> uint(c)) & 1 == 1 {
+					swap(c, order[k])
+					break
+				}
+			}
+
+			if (right[c] >> uint(c)) & 1 == 0 {
+				return blockMatrix{}, 0, false
+			}
+
+			for k := 0; k < 64; k += 1 {
+				if k != c && (right[k] >> uint(c)) & 1 == 1 {
+					left[k] ^= left[c]
+					right[k] ^= right[c]
+				}
+			}
+
+			left[c] = 0
+			right[c] = 0
+		}
+	}
+
+	if ^sPrevious & ^s != 0 {
+		return blockMatrix{}, 0, false
+	}
+
+	return right, s, true
+}
+
+
+/* gaussian elimination by inserting one row after the other. returns the indizes of the rows that are
+independent of the ones before and for every other row the combination (index set as a row) that adds up
+to zero. the rows are modified */
+func eliminate(rows []*Row) ([]int, []*Row) {
+
+	independent := []int{}
+	zeroCombinations := []*Row{}
+
+	combinations := make([]*Row, len(rows))
+
+	/* highest column -> row having it as its highest one */
+	pivots := make(map[int]int)
+
+	for i, row := range rows {
+
+		combinations[i] = NewRow(len(rows))
+		combinations[i].SetColumn(i, 1)
+
+		for {
+			column := row.HighestColumn()
+
+			if column == -1 {
+				zeroCombinations = append(zeroCombinations, combinations[i])
+				break
+			}
+
+			pivot, ok := pivots[column]
+			if ok == false {
+				pivots[column] = i
+				independent = append(independent, i)
+				break
+			}
+
+			row.Xor(row, rows[pivot])
+			combinations[i].Xor(combinations[i], combinations[pivot])
+		}
+	}
+
+	return independent, zeroCombinations
+}
+
+
+/* column j of a block as a row */
+func blockColumnToRow(v []uint64, j int) *Row {
+
+	ret := NewRow(len(v))
+
+	for k, value := range v {
+		if (value >> uint(j)) & 1 == 1 {
+			ret.SetColumn(k, 1)
+		}
+	}
+
+	return ret
+}
+
+
+func identityBlock() blockMatrix {
+
+	var ret blockMatrix
+	for i := range ret {
+		ret[i] = 1 << uint(i)
+	}
+
+	return ret
+}
+
+
+func addBlock(a, b blockMatrix) blockMatrix {
+
+	var ret blockMatrix
+	for i := range ret {
+		ret[i] = a[i] ^ b[i]
+	}
+
+	return ret
+}
+
+
+func mulBlock(a, b blockMatrix) blockMatrix {
+
+	var ret blockMatrix
+	for i, row := range a {
+		for ; row != 0; row &= row - 1 {
+			ret[i] ^= b[bits.TrailingZeros64(row)]
+		}
+	}
+
+	return ret
+}
+
+
+/* M S S^T, keeps the columns in s */
+func maskColumnsBlock(a blockMatrix, s uint64) blockMatrix {
+
+	var ret blockMatrix
+	for i := range ret {
+		ret[i] = a[i] & s
+	}
+
+	return ret
+}
+
+
+/* v M. the rows of M are combined a byte at a time through tables */
+func mulVectorBlock(v []uint64, m blockMatrix) []uint64 {
+
+	var tables [8][256]uint64
+
+	for b := 0; b < 8; b += 1 {
+		for value := 1; value < 256; value += 1 {
+			lowest := bits.TrailingZeros(uint(value))
+			tables[b][value] = tables[b][value & (value - 1)] ^ m[8*b + lowest]
+		}
+	}
+
+	ret := make([]uint64, len(v))
+
+	for k, value := range v {
+		var sum uint64
+		for b := 0; b < 8; b += 1 {
+			sum ^= tables[b][(value >> uint(8*b)) & 0xff]
+		}
+		ret[k] = sum
+	}
+
+	return ret
+}
+
+
+/* v^T w. rows of w are collected per byte value of v and summed up per bit afterwards */
+func innerProductBlock(v, w []uint64) blockMatrix {
+
+	var tables [8][256]uint64
+
+	for k, value := range v {
+		for b := 0; b < 8; b += 1 {
+			tables[b][(value >> uint(8*b)) & 0xff] ^= w[k]
+		}
+	}
+
+	var ret blockMatrix
+
+	for b := 0; b < 8; b += 1 {
+		for value := 1; value < 256; value += 1 {
+			for bit := 0; bit < 8; bit += 1 {
+				if (value >> uint(bit)) & 1 == 1 {
+					ret[8*b + bit] ^= tables[b][value]
+				}
+			}
+		}
+	}
+
+	return ret
+}
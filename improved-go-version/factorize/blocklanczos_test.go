@@ -0,0 +1,80 @@
+package main
+
+
+import (
+	"math/rand"
+	"testing"
+)
+
+
+func TestBlockLanczos(t *testing.T) {
+
+	rng := rand.New(rand.NewSource(1234))
+
+	for _, size := range []struct{ rows, columns int }{{200, 260}, {1000, 1020}, {3000, 3050}} {
+
+		exponents := randomSparseExponents(rng, size.rows, size.columns)
+
+		dependencies := blockLanczosDependencies(exponents)
+
+		if len(dependencies) < 20 {
+			t.Error(size.rows, "x", size.columns, ": only", len(dependencies), "dependencies found")
+		}
+
+		testDependencies(t, exponents, dependencies)
+	}
+}
+
+
+func TestFactorizeBlockLanczos(t *testing.T) {
+
+	nums := []string{
+		"194822769053998839904705444189",
+		"6734319982431950692508617574486143524749",
+	}
+
+	testFactorizeSplits(t, nums, options{source: sourceSIQS, largePrimeMultiplier: 32, solver: solverLanczos})
+}
+
+
+/* a few entries per relation, more of them for small primes */
+func randomSparseExponents(rng *rand.Rand, rows, columns int) [][]int {
+
+	exponents := make([][]int, columns)
+
+	for i := range exponents {
+		exponents[i] = make([]int, rows)
+		for k := 0; k < 20; k += 1 {
+			j := int(float64(rows) * rng.Float64() * rng.Float64())
+			exponents[i][j] += 1
+		}
+	}
+
+	return exponents
+}
+
+
+/* every index set sums up to even exponents */
+func testDependencies(t *testing.T, exponents [][]int, dependencies [][]int) {
+
+	for _, indexSet := range dependencies {
+
+		if len(indexSet) == 0 {
+			t.Error("empty dependency")
+		}
+
+		sum := make([]int, len(exponents[0]))
+		for _, i := range indexSet {
+			for j, e := range exponents[i] {
+				sum[j] += e
+			}
+		}
+
+		for j, e := range sum {
+			if e % 2 != 0 {
+				t.Error("dependency", indexSet, "has an odd exponent at", j)
+				break
+			}
+		}
+	}
+}
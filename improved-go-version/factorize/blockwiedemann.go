@@ -0,0 +1,306 @@
+package main
+
+/* coppersmith's block wiedemann over GF(2).
+ *
+ * A is B padded with zero rows to a square matrix, it doesn't have to be symmetric (unlike for block
+ * lanczos) and its kernel is the one of B. for a random 64-column block X and blocks Y_b = A Z_b (b < sequences)
+ * the sequences a_i = X^T A^i Y_b are computed independently of each other. the block berlekamp-massey
+ * algorithm finds vector polynomials f with sum_k a_(t-k) f_k = 0 for all large enough t. for those
+ * w = sum_k A^(deg f - k) Z f_k is almost always mapped to zero by a small power of A. */
+
+import (
+	"math/bits"
+	"math/rand"
+	"sort"
+)
+
+
+/* number of independent sequences (of 64 columns of Y each), computed on separate goroutines */
+const wiedemannSequences = 4
+
+/* how often block wiedemann is restarted with other random blocks before giving up */
+const wiedemannAttempts = 3
+
+
+/* a column of the generator in the berlekamp-massey algorithm. coefficient k holds one word per sequence,
+the degree is the nominal one */
+type wiedemannColumn struct {
+	coefficients [][]uint64
+	degree int
+}
+
+
+/* the dependencies between the relations (index sets), nil if block wiedemann failed */
+func blockWiedemannDependencies(exponents [][]int) [][]int {
+
+	matrix := sparseMatrixFromExponents(exponents)
+
+	for seed := int64(1); seed <= wiedemannAttempts; seed += 1 {
+		if dependencies := blockWiedemann(matrix, seed); len(dependencies) > 0 {
+			return dependencies
+		}
+	}
+
+	return nil
+}
+
+
+/* up to 64 independent dependencies between the columns of B, nil if none were found. every generator
+column yields a vector in the kernel, only together they span a good part of it */
+func blockWiedemann(matrix *SparseMatrix, seed int64) [][]int {
+
+	n := matrix.columnCount
+
+	if matrix.rowCount >= n {
+		return nil
+	}
+
+	rng := rand.New(rand.NewSource(seed))
+
+	mulA := func(v []uint64) []uint64 {
+		ret := make([]uint64, n)
+		copy(ret, matrix.Mul(v))
+		return ret
+	}
+
+	randomBlock := func() []uint64 {
+		ret := make([]uint64, n)
+		for k := range ret {
+			ret[k] = rng.Uint64()
+		}
+		return ret
+	}
+
+	x := randomBlock()
+
+	z := make([][]uint64, wiedemannSequences)
+	for b := range z {
+		z[b] = randomBlock()
+	}
+
+	/* n / 64 + n / (64 sequences) terms determine the generator, a few more make it reliable */
+	length := n / 64 + n / (64 * wiedemannSequences) + 16
+
+	/* the transposed a_i per sequence: sequence[b][i][c] is column c of X^T A^i Y_b */
+	sequence := make([][]blockMatrix, wiedemannSequences)
+
+	done := make(chan bool)
+
+	for b := range z {
+		go func(b int) {
+			sequence[b] = wiedemannSequence(mulA, x, z[b], length)
+			done <- true
+		}(b)
+	}
+
+	for _ = range z {
+		<-done
+	}
+
+	generator := blockBerlekampMassey(sequence, length)
+	if generator == nil {
+		return nil
+	}
+
+	/* A w is almost always 0 already, A^2 w covers the rest */
+	blocks := [][]uint64{}
+
+	for i := 0; i < len(generator); i += 64 {
+		w := wiedemannSolution(mulA, z, generator[i:i+64])
+		aw := mulA(w)
+		blocks = append(blocks, w, aw, mulA(aw))
+	}
+
+	return nullspaceFromBlocks(matrix, blocks)
+}
+
+
+/* w = sum_k A^(deg f - k) Z f_k for 64 generator columns at once (horner) */
+func wiedemannSolution(mulA func([]uint64) []uint64, z [][]uint64, generator []wiedemannColumn) []uint64 {
+
+	maxDegree := 0
+	for _, column := range generator {
+		if column.degree > maxDegree {
+			maxDegree = column.degree
+		}
+	}
+
+	w := make([]uint64, len(z[0]))
+
+	for i := 0; i <= maxDegree; i += 1 {
+
+		w = mulA(w)
+
+		for b := range z {
+
+			/* row c: which generator columns use column c of Z_b */
+			var g blockMatrix
+			for j, column := range generator {
+				k := i - (maxDegree - column.degree)
+				if k < 0 || k >= len(column.coefficients) {
+					continue
+				}
+				for c := column.coefficients[k][b]; c != 0; c &= c - 1 {
+					g[bits.TrailingZeros64(c)] |= 1 << uint(j)
+				}
+			}
+
+			zg := mulVectorBlock(z[b], g)
+			for k := range w {
+				w[k] ^= zg[k]
+			}
+		}
+	}
+
+	return w
+}
+
+
+/* X^T A^i Y, transposed, for i < length. Y = A Z */
+func wiedemannSequence(mulA func([]uint64) []uint64, x, z []uint64, length int) []blockMatrix {
+
+	ret := make([]blockMatrix, length)
+
+	v := mulA(z)
+
+	for i := 0; i < length; i += 1 {
+		ret[i] = innerProductBlock(v, x)
+		v = mulA(v)
+	}
+
+	return ret
+}
+
+
+/* the 64 sequences columns of lowest degree of a generator f with sum_k a_(t-k) f_k = 0 for deg f <= t < length.
+nil if the sequence is degenerate */
+func blockBerlekampMassey(sequence [][]blockMatrix, length int) []wiedemannColumn {
+
+	sequences := len(sequence)
+
+	/* the discrepancy, coefficient t of a(x) f(x) */
+	discrepancy := func(column wiedemannColumn, t int) uint64 {
+		var e uint64
+		for k := 0; k <= t && k < len(column.coefficients); k += 1 {
+			for b := 0; b < sequences; b += 1 {
+				a := &sequence[b][t - k]
+				for c := column.coefficients[k][b]; c != 0; c &= c - 1 {
+					e ^= a[bits.TrailingZeros64(c)]
+				}
+			}
+		}
+		return e
+	}
+
+	/* f starts as [I | x^(t0-k) e_r] where the columns r of a_k for the second part are 64 independent ones.
+	all columns have degree t0, the discrepancy at t0 has full rank */
+	f := []wiedemannColumn{}
+
+	for b := 0; b < sequences; b += 1 {
+		for c := 0; c < 64; c += 1 {
+			coefficients := [][]uint64{make([]uint64, sequences)}
+			coefficients[0][b] = 1 << uint(c)
+			f = append(f, wiedemannColumn{coefficients, 0})
+		}
+	}
+
+	type selection struct {
+		k, b, c int
+	}
+
+	selected := []selection{}
+	pivots := make(map[int]uint64) /* row -> reduced vector */
+
+	for k := 0; k < length && len(selected) < 64; k += 1 {
+		for b := 0; b < sequences && len(selected) < 64; b += 1 {
+			for c := 0; c < 64 && len(selected) < 64; c += 1 {
+
+				v := sequence[b][k][c]
+				for v != 0 {
+					row := bits.TrailingZeros64(v)
+					pivot, ok := pivots[row]
+					if ok == false {
+						pivots[row] = v
+						selected = append(selected, selection{k, b, c})
+						break
+					}
+					v ^= pivot
+				}
+			}
+		}
+	}
+
+	if len(selected) < 64 {
+		return nil
+	}
+
+	t0 := selected[len(selected)-1].k + 1
+
+	for i := range f {
+		f[i].degree = t0
+	}
+
+	for _, s := range selected {
+		coefficients := make([][]uint64, t0 - s.k + 1)
+		for k := range coefficients {
+			coefficients[k] = make([]uint64, sequences)
+		}
+		coefficients[t0 - s.k][s.b] = 1 << uint(s.c)
+		f = append(f, wiedemannColumn{coefficients, t0})
+	}
+
+	for t := t0; t < length; t += 1 {
+
+		e := make([]uint64, len(f))
+		for j := range f {
+			e[j] = discrepancy(f[j], t)
+		}
+
+		/* gaussian elimination on the discrepancy. columns are only added to ones of at least the same
+		degree, the pivots get multiplied by x */
+		order := make([]int, len(f))
+		for j := range order {
+			order[j] = j
+		}
+		sort.SliceStable(order, func(i, j int) bool { return f[order[i]].degree < f[order[j]].degree })
+
+		pivotColumns := []int{}
+
+		for _, j := range order {
+
+			for _, p := range pivotColumns {
+				if (e[j] >> uint(bits.TrailingZeros64(e[p]))) & 1 == 1 {
+					e[j] ^= e[p]
+					f[j].add(f[p])
+				}
+			}
+
+			if e[j] != 0 {
+				pivotColumns = append(pivotColumns, j)
+			}
+		}
+
+		for _, p := range pivotColumns {
+			f[p].coefficients = append([][]uint64{make([]uint64, sequences)}, f[p].coefficients...)
+			f[p].degree += 1
+		}
+	}
+
+	sort.SliceStable(f, func(i, j int) bool { return f[i].degree < f[j].degree })
+
+	return f[:64*sequences]
+}
+
+
+func (this *wiedemannColumn) add(other wiedemannColumn) {
+
+	for len(this.coefficients) < len(other.coefficients) {
+		this.coefficients = append(this.coefficients, make([]uint64, len(other.coefficients[0])))
+	}
+
+	for k, coefficient := range other.coefficients {
+		for b, word := range coefficient {
+			this.coefficients[k][b] ^= word
+		}
+	}
+}
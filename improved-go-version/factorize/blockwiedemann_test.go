@@ -0,0 +1,43 @@
+package main
+
+
+import (
+	"math/rand"
+	"testing"
+)
+
+
+/* both iterative solvers on the same matrices */
+func TestBlockWiedemann(t *testing.T) {
+
+	rng := rand.New(rand.NewSource(4321))
+
+	for _, size := range []struct{ rows, columns int }{{200, 260}, {1000, 1020}, {3000, 3050}} {
+
+		exponents := randomSparseExponents(rng, size.rows, size.columns)
+
+		wiedemann := blockWiedemannDependencies(exponents)
+		lanczos := blockLanczosDependencies(exponents)
+
+		if len(wiedemann) < 20 {
+			t.Error(size.rows, "x", size.columns, ": only", len(wiedemann), "dependencies found")
+		}
+
+		if len(lanczos) < 20 {
+			t.Error(size.rows, "x", size.columns, ": block lanczos found only", len(lanczos), "dependencies")
+		}
+
+		testDependencies(t, exponents, wiedemann)
+	}
+}
+
+
+func TestFactorizeBlockWiedemann(t *testing.T) {
+
+	nums := []string{
+		"194822769053998839904705444189",
+		"6734319982431950692508617574486143524749",
+	}
+
+	testFactorizeSplits(t, nums, options{source: sourceSIQS, largePrimeMultiplier: 32, solver: solverWiedemann})
+}
@@ -0,0 +1,95 @@
+package main
+
+/* continued fraction factorization (morrison and brillhart).
+ *
+ * the continued fraction of sqrt(n) = [q_0; q_1, q_2, ...] has convergents A_i / B_i with
+ * A_i^2 = (-1)^(i+1) Q_i+1 (mod n), where 0 < Q_i < 2 sqrt(n). so the small Q_i take the place of the sieve
+ * values c^2 - n, with c(i) = A_i mod n. there is nothing to sieve, every Q_i is trial divided over the factor
+ * base. the expansion is done with integers only:
+ *
+ *   P_0 = 0, Q_0 = 1, q_0 = floor(sqrt(n)), A_-2 = 0, A_-1 = 1
+ *   A_i = q_i A_i-1 + A_i-2 (mod n)
+ *   P_i+1 = q_i Q_i - P_i
+ *   Q_i+1 = (n - P_i+1^2) / Q_i
+ *   q_i+1 = floor((q_0 + P_i+1) / Q_i+1)
+ *
+ * p | Q_i implies that n is a square mod p, so the primes are the same as for the quadratic sieve.
+ *
+ * the expansion is periodic and every period is symmetric around P_i = P_i+1 or Q_i = Q_i+1. the Q_i after
+ * that point repeat those before it, and every dependency between the two copies of a relation is trivial.
+ * so the expansion stops there, which is early only for tiny n. a multiplier k with a different expansion of
+ * sqrt(k n) may help for those. */
+
+import (
+	"math/big"
+)
+
+
+/* give up after this many steps of the expansion */
+const cfracMaxSteps = 1 << 22
+
+
+func cfrac(n *big.Int, factorBase []*big.Int, wanted int, relations *relationSet) {
+
+	root := big.NewInt(0).Sqrt(n)
+
+	if big.NewInt(0).Mul(root, root).Cmp(n) == 0 {
+		/* n is a square, the expansion ends right away */
+		return
+	}
+
+	P := big.NewInt(0)
+	Q := big.NewInt(1)
+	q := big.NewInt(0).Set(root)
+
+	Pprevious := big.NewInt(0)
+	Qprevious := big.NewInt(0)
+
+	A := big.NewInt(1) /* A_i-1 */
+	Aprevious := big.NewInt(0) /* A_i-2 */
+
+	t := big.NewInt(0)
+	di := big.NewInt(0)
+	cofactor := big.NewInt(0)
+	exponents := make([]int, len(factorBase))
+
+	for i := 0; i < cfracMaxSteps && relations.count() < wanted; i += 1 {
+
+		/* A_i */
+		t.Mul(q, A)
+		t.Add(t, Aprevious)
+		t.Mod(t, n)
+		Aprevious.Set(A)
+		A.Set(t)
+
+		/* P_i+1, Q_i+1, q_i+1 */
+		Pprevious.Set(P)
+		Qprevious.Set(Q)
+
+		t.Mul(q, Q)
+		P.Sub(t, P)
+
+		t.Mul(P, P)
+		t.Sub(n, t)
+		Q.Quo(t, Q)
+
+		if i > 0 && (P.Cmp(Pprevious) == 0 || Q.Cmp(Qprevious) == 0) {
+			/* the middle of the period */
+			return
+		}
+
+		t.Add(root, P)
+		q.Quo(t, Q)
+
+		/* A_i^2 = (-1)^(i+1) Q_i+1 */
+		di.Set(Q)
+		if i % 2 == 0 {
+			di.Neg(di)
+		}
+
+		cofactor.Set(di)
+		trialDivide(cofactor, 0, factorBase, nil, nil, exponents)
+
+		relations.add(A, di, exponents, cofactor)
+	}
+}
@@ -0,0 +1,34 @@
+package main
+
+
+import (
+	"math/big"
+	"testing"
+)
+
+
+func TestCFRAC(t *testing.T) {
+
+	n := big.NewInt(2626849055875147)
+
+	factorBase, _ := factorBase(n, 0)
+	relations := newRelationSet(n, factorBase, 32, false)
+
+	cfrac(n, factorBase, len(factorBase) + extraRelations, relations)
+
+	if relations.count() < len(factorBase) + extraRelations {
+		t.Error("cfrac found", relations.count(), "of", len(factorBase) + extraRelations, "relations")
+	}
+
+	testRelations(t, n, factorBase, relations)
+
+	nums := []string{
+			"1000036000099",
+			"2626849055875147",
+			}
+
+	/* the same back end as for the sieves */
+	for _, solver := range []linearSolver{solverGauss, solverLanczos, solverWiedemann} {
+		testFactorizeSplits(t, nums, options{source: sourceCFRAC, largePrimeMultiplier: 32, solver: solver})
+	}
+}
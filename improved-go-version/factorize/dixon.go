@@ -0,0 +1,56 @@
+package main
+
+/* dixon's random squares.
+ *
+ * x is picked at random from [sqrt(n), n) and the least absolute residue of x^2 mod n trial divided over the
+ * factor base, so c(i) = x and d(i) = x^2 (mod n) with |d(i)| <= n/2. those are as large as n itself, not
+ * about sqrt(n) like the values of the sieves, and smooth ones are rare. it is slow by design: there is no
+ * sieve or polynomial that could go wrong, which makes it a reference for the rest of the pipeline. */
+
+import (
+	"math/big"
+	"math/rand"
+
+	"github.com/hydroo/quadratic-sieve/misc"
+)
+
+
+/* give up after this many x */
+const dixonMaxTries = 1 << 20
+
+
+func dixon(n *big.Int, factorBase []*big.Int, wanted int, relations *relationSet) {
+
+	rng := rand.New(rand.NewSource(1)) /* fixed seed, results are reproducible */
+
+	root := misc.SquareRootCeil(n)
+	width := big.NewInt(0).Sub(n, root)
+
+	if width.Sign() <= 0 {
+		return
+	}
+
+	half := big.NewInt(0).Rsh(n, 1)
+
+	x := big.NewInt(0)
+	di := big.NewInt(0)
+	cofactor := big.NewInt(0)
+	exponents := make([]int, len(factorBase))
+
+	for tries := 0; tries < dixonMaxTries && relations.count() < wanted; tries += 1 {
+
+		x.Rand(rng, width)
+		x.Add(x, root)
+
+		di.Mul(x, x)
+		di.Mod(di, n)
+		if di.Cmp(half) > 0 {
+			di.Sub(di, n)
+		}
+
+		cofactor.Set(di)
+		trialDivide(cofactor, 0, factorBase, nil, nil, exponents)
+
+		relations.add(x, di, exponents, cofactor)
+	}
+}
@@ -0,0 +1,50 @@
+package main
+
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/hydroo/quadratic-sieve/misc"
+)
+
+
+func TestDixon(t *testing.T) {
+
+	n := big.NewInt(40198364677)
+
+	factorBase, _ := factorBase(n, 60)
+	relations := newRelationSet(n, factorBase, 32, false)
+
+	dixon(n, factorBase, len(factorBase) + extraRelations, relations)
+
+	if relations.count() < len(factorBase) + extraRelations {
+		t.Error("dixon found", relations.count(), "of", len(factorBase) + extraRelations, "relations")
+	}
+
+	testRelations(t, n, factorBase, relations)
+
+	/* nothing but the linear algebra and the square root between these relations and a split */
+	for _, solver := range []linearSolver{solverGauss, solverLanczos, solverWiedemann} {
+		for _, filter := range []bool{false, true} {
+
+			x, y := findXandY(n, relations.cis, relations.dis, relations.exponents, solver, filter)
+			x, y = factorsOfN(n, x, y)
+
+			if x == nil || y == nil || big.NewInt(0).Mul(x, y).Cmp(n) != 0 || x.Cmp(misc.One) == 0 || y.Cmp(misc.One) == 0 {
+				t.Error("solver", solver, "filter", filter, "did not split", n, "with the relations of dixon:", x, y)
+			}
+		}
+	}
+
+	testFactorizeSplits(t, []string{n.String()}, options{source: sourceDixon, largePrimeMultiplier: 32, factorBaseSize: 60})
+
+	/* the factor base from dixonParameterTable */
+	testFactorizeSplits(t, []string{n.String()}, options{source: sourceDixon})
+
+	/* too large, an error instead of running until dixonMaxTries */
+	n, _ = big.NewInt(0).SetString("604391025795361445013240465331", 10)
+	if x, y, err := factorize(n, options{source: sourceDixon}); err == nil {
+		t.Error("dixon on", n, "gave", x, y, "instead of an error")
+	}
+}
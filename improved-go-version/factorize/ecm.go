@@ -0,0 +1,366 @@
+package main
+
+/* lenstra's elliptic curve method.
+ *
+ * p-1 with the group (Z/pZ)* of order p - 1 replaced by the points of a random curve mod p, whose order is
+ * anywhere around p + 1 +- 2 sqrt(p). every curve is a new chance at a smooth group order.
+ *
+ * the curves are montgomery curves B y^2 = x^3 + A x^2 + x with suyama's parametrization: for sigma >= 6,
+ * u = sigma^2 - 5, v = 4 sigma, the point (u^3 : v^3) lies on the curve with (A + 2) / 4 = (v - u)^3 (3u + v) /
+ * (16 u^3 v), and the group order is divisible by 12. only x and z are kept, which needs the difference P - Q to
+ * add P + Q, hence the montgomery ladder.
+ *
+ * stage 1 multiplies the point by E, the product of the largest prime powers up to B1, computed once for all
+ * curves. stage 2 catches one more prime B1 < q <= B2 with baby steps [j]Q for 0 < j < D/2, gcd(j, D) = 1, and
+ * giant steps [kD]Q: q = kD +- j and [kD]Q = +-[j]Q (mod p) iff X_kD Z_j - X_j Z_kD = 0 (mod p).
+ *
+ * the curves run in parallel, their sigmas come from a seeded generator in a fixed order. */
+
+import (
+	"math/big"
+	"math/rand"
+	"runtime"
+
+	"github.com/hydroo/quadratic-sieve/misc"
+)
+
+
+/* bounds and curves unless given on the command line, good for factors of about 25 digits */
+const ecmDefaultB1 = 50000
+const ecmDefaultB2 = 100 * ecmDefaultB1
+const ecmDefaultCurves = 300
+
+/* giant step width of stage 2. B1 is raised to at least this */
+const ecmStage2Width = 2 * 3 * 5 * 7
+
+
+/* a non-trivial factor of n or nil after trying the given number of curves. the bounds are those of
+PollardPMinus1() with the defaults of ecm, curves <= 0 takes ecmDefaultCurves. seed picks the curves */
+func ECM(n *big.Int, B1, B2 int64, curves int, seed int64) *big.Int {
+
+	B1, B2 = stageBounds(B1, B2, ecmDefaultB1, ecmDefaultB2)
+
+	if B1 < ecmStage2Width {
+		B1 = ecmStage2Width
+	}
+
+	if B2 < B1 {
+		B2 = B1
+	}
+
+	if curves <= 0 {
+		curves = ecmDefaultCurves
+	}
+
+	if n.Bit(0) == 0 {
+		return big.NewInt(2)
+	}
+
+	E := stage1Exponent(B1)
+	primes := misc.Primes(B2)
+
+	threads := runtime.GOMAXPROCS(-1)
+
+	sigmas := make(chan int64)
+	found := make(chan *big.Int) /* one factor or nil from every worker when it is done */
+	stop := make(chan bool)
+
+	go func() {
+		defer close(sigmas)
+		rng := rand.New(rand.NewSource(seed))
+		for i := 0; i < curves; i += 1 {
+			select {
+			case sigmas <- 6 + rng.Int63n(1 << 32):
+			case <-stop:
+				return
+			}
+		}
+	}()
+
+	for i := 0; i < threads; i += 1 {
+		go func() {
+			var f *big.Int
+			for sigma := range sigmas {
+				if f = ecmCurve(n, sigma, E, B1, primes); f != nil {
+					break
+				}
+			}
+			found <- f
+		}()
+	}
+
+	/* the first factor stops the generator, the curves in flight are finished */
+	var ret *big.Int
+
+	for running := threads; running > 0; running -= 1 {
+		if f := <-found; f != nil && ret == nil {
+			ret = f
+			close(stop)
+		}
+	}
+
+	return ret
+}
+
+
+/* the product of the largest powers of the primes up to B1 that are not above B1 */
+func stage1Exponent(B1 int64) *big.Int {
+
+	ret := big.NewInt(1)
+	P := big.NewInt(0)
+
+	for _, p := range misc.Primes(B1) {
+		ret.Mul(ret, P.SetInt64(largestPowerUpTo(p, B1)))
+	}
+
+	return ret
+}
+
+
+/* both stages on the curve of sigma. a non-trivial factor of n or nil */
+func ecmCurve(n *big.Int, sigma int64, E *big.Int, B1 int64, primes []int64) *big.Int {
+
+	curve, P, f := newSuyamaCurve(n, sigma)
+	if curve == nil {
+		return f
+	}
+
+	/* stage 1 */
+	Q := curve.newPoint()
+	curve.multiply(Q, P, E)
+
+	g := big.NewInt(0).GCD(nil, nil, Q.Z, n)
+
+	if g.Cmp(misc.One) != 0 {
+		return nonTrivialFactor(g, n)
+	}
+
+	/* stage 2 */
+	const D = ecmStage2Width
+
+	/* baby[j] = [j]Q for odd j < D/2, nil unless gcd(j, D) = 1 */
+	baby := make([]montgomeryPoint, D/2)
+
+	Q2 := curve.newPoint()
+	curve.double(Q2, Q)
+
+	previous := curve.newPoint()
+	current := curve.newPoint()
+	current.set(Q)
+
+	for j := 1; j < D/2; j += 2 {
+
+		if j % 3 != 0 && j % 5 != 0 && j % 7 != 0 {
+			baby[j] = curve.newPoint()
+			baby[j].set(current)
+		}
+
+		/* [j+2]Q = [j]Q + [2]Q, difference [j-2]Q */
+		next := curve.newPoint()
+		if j == 1 {
+			curve.add(next, current, Q2, Q)
+		} else {
+			curve.add(next, current, Q2, previous)
+		}
+		previous, current = current, next
+	}
+
+	/* giant steps G_k = [kD]Q, G_k+1 = G_k + [D]Q with difference G_k-1, G_2 = 2 G_1. B1 >= D makes k >= 1 */
+	step := curve.newPoint()
+	curve.multiply(step, Q, big.NewInt(D))
+
+	k := (B1 + D/2) / D
+	giant := curve.newPoint()
+	curve.multiply(giant, Q, big.NewInt(k * D))
+	before := curve.newPoint()
+	if k > 1 {
+		curve.multiply(before, Q, big.NewInt((k - 1) * D))
+	}
+
+	product := big.NewInt(1)
+	s := big.NewInt(0)
+	t := big.NewInt(0)
+	count := 0
+
+	for _, q := range primes {
+
+		if q <= B1 {
+			continue
+		}
+
+		for ; k < (q + D/2) / D; k += 1 {
+			after := curve.newPoint()
+			if k == 1 {
+				curve.double(after, giant)
+			} else {
+				curve.add(after, giant, step, before)
+			}
+			before, giant = giant, after
+		}
+
+		j := q - k * D
+		if j < 0 {
+			j = -j
+		}
+
+		s.Mul(giant.X, baby[j].Z)
+		t.Mul(baby[j].X, giant.Z)
+		s.Sub(s, t)
+		product.Mul(product, s)
+		product.Mod(product, n)
+
+		count += 1
+		if count % stage2Batch == 0 {
+			if g.GCD(nil, nil, product, n).Cmp(misc.One) != 0 {
+				return nonTrivialFactor(g, n)
+			}
+		}
+	}
+
+	return nonTrivialFactor(g.GCD(nil, nil, product, n), n)
+}
+
+
+/* *** montgomeryCurve *** ************************************************* */
+
+/* B y^2 = x^3 + A x^2 + x mod n, only a24 = (A + 2) / 4 is needed */
+type montgomeryCurve struct {
+	n *big.Int
+	a24 *big.Int
+
+	/* scratch space of double() and add() */
+	t1, t2, t3, t4 *big.Int
+}
+
+
+/* (X : Z) */
+type montgomeryPoint struct {
+	X, Z *big.Int
+}
+
+
+/* the curve and point of suyama's parametrization for sigma. if an inverse mod n does not exist, a nil curve and
+the factor it reveals, which is nil if it is n */
+func newSuyamaCurve(n *big.Int, sigma int64) (*montgomeryCurve, montgomeryPoint, *big.Int) {
+
+	u := big.NewInt(sigma)
+	u.Mul(u, u)
+	u.Sub(u, big.NewInt(5))
+	u.Mod(u, n)
+
+	v := big.NewInt(4 * sigma)
+	v.Mod(v, n)
+
+	/* x = u^3, z = v^3 */
+	P := montgomeryPoint{big.NewInt(0).Exp(u, big.NewInt(3), n), big.NewInt(0).Exp(v, big.NewInt(3), n)}
+
+	/* a24 = (v - u)^3 (3u + v) / (16 u^3 v) */
+	numerator := big.NewInt(0).Sub(v, u)
+	numerator.Exp(numerator, big.NewInt(3), n)
+	t := big.NewInt(3)
+	t.Mul(t, u)
+	t.Add(t, v)
+	numerator.Mul(numerator, t)
+	numerator.Mod(numerator, n)
+
+	denominator := big.NewInt(16)
+	denominator.Mul(denominator, P.X)
+	denominator.Mul(denominator, v)
+	denominator.Mod(denominator, n)
+
+	g := big.NewInt(0).GCD(nil, nil, denominator, n)
+	if g.Cmp(misc.One) != 0 {
+		return nil, P, nonTrivialFactor(g, n)
+	}
+
+	a24 := denominator.ModInverse(denominator, n)
+	a24.Mul(a24, numerator)
+	a24.Mod(a24, n)
+
+	return &montgomeryCurve{n, a24, big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0)}, P, nil
+}
+
+
+func (this *montgomeryCurve) newPoint() montgomeryPoint {
+	return montgomeryPoint{big.NewInt(0), big.NewInt(0)}
+}
+
+
+func (this montgomeryPoint) set(P montgomeryPoint) {
+	this.X.Set(P.X)
+	this.Z.Set(P.Z)
+}
+
+
+/* R = 2P. R may be P */
+func (this *montgomeryCurve) double(R, P montgomeryPoint) {
+
+	n := this.n
+	t1, t2, t3 := this.t1, this.t2, this.t3
+
+	/* t1 = (X + Z)^2, t2 = (X - Z)^2, t3 = t1 - t2 = 4XZ */
+	t1.Add(P.X, P.Z)
+	t1.Mul(t1, t1)
+	t1.Mod(t1, n)
+	t2.Sub(P.X, P.Z)
+	t2.Mul(t2, t2)
+	t2.Mod(t2, n)
+	t3.Sub(t1, t2)
+
+	/* X = t1 t2, Z = t3 (t2 + a24 t3) */
+	R.X.Mul(t1, t2)
+	R.X.Mod(R.X, n)
+	t1.Mul(this.a24, t3)
+	t1.Add(t1, t2)
+	R.Z.Mul(t1, t3)
+	R.Z.Mod(R.Z, n)
+}
+
+
+/* R = P + Q given D = P - Q. R may be P or Q, but not D */
+func (this *montgomeryCurve) add(R, P, Q, D montgomeryPoint) {
+
+	n := this.n
+	t1, t2, t3, t4 := this.t1, this.t2, this.t3, this.t4
+
+	/* t1 = (XP - ZP)(XQ + ZQ), t2 = (XP + ZP)(XQ - ZQ) */
+	t3.Sub(P.X, P.Z)
+	t4.Add(Q.X, Q.Z)
+	t1.Mul(t3, t4)
+	t1.Mod(t1, n)
+	t3.Add(P.X, P.Z)
+	t4.Sub(Q.X, Q.Z)
+	t2.Mul(t3, t4)
+	t2.Mod(t2, n)
+
+	/* X = ZD (t1 + t2)^2, Z = XD (t1 - t2)^2 */
+	t3.Add(t1, t2)
+	t3.Mul(t3, t3)
+	t4.Sub(t1, t2)
+	t4.Mul(t4, t4)
+
+	R.X.Mul(D.Z, t3)
+	R.X.Mod(R.X, n)
+	R.Z.Mul(D.X, t4)
+	R.Z.Mod(R.Z, n)
+}
+
+
+/* R = [k]P for k > 0 with the montgomery ladder. R must not be P */
+func (this *montgomeryCurve) multiply(R, P montgomeryPoint, k *big.Int) {
+
+	/* R = [j]P, S = [j+1]P for the leading bits j of k */
+	S := this.newPoint()
+	R.set(P)
+	this.double(S, P)
+
+	for i := k.BitLen() - 2; i >= 0; i -= 1 {
+		if k.Bit(i) == 1 {
+			this.add(R, R, S, P)
+			this.double(S, S)
+		} else {
+			this.add(S, S, R, P)
+			this.double(R, R)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package main
+
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/hydroo/quadratic-sieve/misc"
+)
+
+
+func TestMontgomeryCurve(t *testing.T) {
+
+	n := big.NewInt(0).Mul(big.NewInt(1000003), big.NewInt(1000033))
+
+	curve, P, f := newSuyamaCurve(n, 11)
+	if curve == nil {
+		t.Fatal("newSuyamaCurve(", n, ", 11) failed with", f)
+	}
+
+	/* x(P) as X / Z, P at infinity is nil */
+	x := func(P montgomeryPoint) *big.Int {
+		ret := big.NewInt(0).ModInverse(P.Z, n)
+		if ret == nil {
+			return nil
+		}
+		return ret.Mul(ret, P.X).Mod(ret, n)
+	}
+
+	multiple := func(k int64) montgomeryPoint {
+		ret := curve.newPoint()
+		curve.multiply(ret, P, big.NewInt(k))
+		return ret
+	}
+
+	/* 2P by double(), add() and multiply() */
+	R := curve.newPoint()
+	curve.double(R, P)
+	if x(R).Cmp(x(multiple(2))) != 0 {
+		t.Error("double(P) =", x(R), "and multiply(P, 2) =", x(multiple(2)), "differ")
+	}
+
+	/* 7P = 4P + 3P with difference P */
+	curve.add(R, multiple(4), multiple(3), P)
+	if x(R).Cmp(x(multiple(7))) != 0 {
+		t.Error("4P + 3P =", x(R), "and multiply(P, 7) =", x(multiple(7)), "differ")
+	}
+
+	/* [6]([5]P) = [30]P */
+	S := curve.newPoint()
+	curve.multiply(S, multiple(5), big.NewInt(6))
+	if x(S).Cmp(x(multiple(30))) != 0 {
+		t.Error("6 (5P) =", x(S), "and 30P =", x(multiple(30)), "differ")
+	}
+}
+
+
+func TestECM(t *testing.T) {
+
+	R, _ := big.NewInt(0).SetString(safePrime, 10)
+
+	for _, p := range []int64{110560571129, 197430698383003} {
+
+		n := big.NewInt(0).Mul(big.NewInt(p), R)
+
+		if f := ECM(n, 2000, 200000, 200, 1); f == nil || f.Int64() != p {
+			t.Error("ECM(", n, ") =", f, "instead of", p)
+		}
+
+		factors, err := factorCompletely(n, options{method: methodECM, B1: 2000, B2: 200000, curves: 200})
+		if err != nil {
+			t.Error(n, err)
+		} else {
+			testFactorization(t, n, factors)
+		}
+	}
+}
+
+
+func TestECMStage2(t *testing.T) {
+
+	R, _ := big.NewInt(0).SetString(safePrime, 10)
+	p := big.NewInt(197430698383003)
+	n := big.NewInt(0).Mul(p, R)
+
+	E := stage1Exponent(2000)
+
+	/* the group order of the curve of sigma = 25 mod p has one prime between 2000 and 200000 */
+	if f := ecmCurve(n, 25, E, 2000, nil); f != nil {
+		t.Error("stage 1 of sigma = 25 found", f)
+	}
+
+	if f := ecmCurve(n, 25, E, 2000, misc.Primes(200000)); f == nil || f.Cmp(p) != 0 {
+		t.Error("stage 2 of sigma = 25 found", f, "instead of", p)
+	}
+}
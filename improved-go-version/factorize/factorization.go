@@ -0,0 +1,240 @@
+package main
+
+/* complete prime factorization.
+ *
+ * a cheap front stage strips the small primes by trial division. what is left is checked for being a perfect
+ * power or prime, only genuine composites go to split(). it splits them by opts.method, qs and gnfs try
+ * fermat's method for close factors first. either of x and y may be composite again, so the pieces go through
+ * the same checks until only primes are left */
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hydroo/quadratic-sieve/misc"
+)
+
+
+/* *** PrimeFactor *** ***************************************************** */
+type PrimeFactor struct {
+	Prime *big.Int
+	Exponent int
+}
+
+
+/* p or p^k */
+func (this PrimeFactor) String() string {
+	if this.Exponent == 1 {
+		return this.Prime.String()
+	}
+	return fmt.Sprint(this.Prime, "^", this.Exponent)
+}
+
+
+/* the prime factors of n > 0 sorted by prime, with the default options of the factor command */
+func FactorCompletely(n *big.Int) ([]PrimeFactor, error) {
+	return factorCompletely(n, defaultOptions())
+}
+
+
+func factorCompletely(n *big.Int, opts options) ([]PrimeFactor, error) {
+
+	if n.Sign() <= 0 {
+		return nil, fmt.Errorf("%v is not positive", n)
+	}
+
+	exponents := make(map[string]int)
+	primes := []*big.Int{}
+
+	addPrime := func(p *big.Int, exponent int) {
+		key := p.String()
+		if _, ok := exponents[key]; ok == false {
+			primes = append(primes, p)
+		}
+		exponents[key] += exponent
+	}
+
+	small, m := divideSmallPrimes(n)
+	for _, factor := range small {
+		addPrime(factor.Prime, factor.Exponent)
+	}
+
+	/* composite^exponent divides n */
+	type piece struct {
+		composite *big.Int
+		exponent int
+	}
+
+	pieces := []piece{{m, 1}}
+
+	for len(pieces) > 0 {
+
+		m := pieces[len(pieces)-1].composite
+		exponent := pieces[len(pieces)-1].exponent
+		pieces = pieces[:len(pieces)-1]
+
+		if m.Cmp(misc.One) == 0 {
+			continue
+		}
+
+		if base, k := misc.PerfectPower(m); k > 1 {
+			pieces = append(pieces, piece{base, exponent * k})
+			continue
+		}
+
+		if isProbablePrime(m) == true {
+			addPrime(m, exponent)
+			continue
+		}
+
+		x, y, err := split(m, opts)
+		if err != nil {
+			return nil, err
+		} else if x == nil || y == nil {
+			return nil, fmt.Errorf("%v could not be split", m)
+		}
+
+		pieces = append(pieces, piece{x, exponent}, piece{y, exponent})
+	}
+
+	sort.Slice(primes, func(i, j int) bool { return primes[i].Cmp(primes[j]) < 0 })
+
+	ret := make([]PrimeFactor, len(primes))
+	for i, p := range primes {
+		ret[i] = PrimeFactor{p, exponents[p.String()]}
+	}
+
+	return ret, nil
+}
+
+
+/* one split of the composite m by opts.method. nil, nil if it failed */
+func split(m *big.Int, opts options) (*big.Int, *big.Int, error) {
+
+	t1 := time.Now()
+
+	var f *big.Int
+	var name string
+
+	/* close factors, e.g. of weak rsa keys, are found right away. rho, p-1, p+1 and ecm run alone */
+	if opts.fermatIterations > 0 && m.IsUint64() == false && (opts.method == methodQS || opts.method == methodGNFS) {
+		f, name = Fermat(m, opts.fermatIterations), "fermat"
+	}
+
+	if f == nil {
+		f, name = splitByMethod(m, opts)
+	}
+
+	if f == nil && opts.method == methodQS {
+		return factorize(m, opts)
+	} else if f == nil && opts.method == methodGNFS {
+		return gnfs(m, opts)
+	} else if f == nil {
+		return nil, nil, nil
+	}
+
+	x, y := f, big.NewInt(0).Quo(m, f)
+	if x.Cmp(y) > 0 {
+		x, y = y, x
+	}
+
+	if opts.benchmark == true {
+		fmt.Println(m, "+", x, y, "wall", nanoSecondsToString(time.Since(t1).Nanoseconds()), name)
+	}
+
+	return x, y, nil
+}
+
+
+/* a factor of m by opts.method and the name of the method, nil if none was found. for methodQS and methodGNFS
+only what comes in front of the sieve */
+func splitByMethod(m *big.Int, opts options) (*big.Int, string) {
+
+	if opts.method == methodPMinus1 {
+		return PollardPMinus1(m, opts.B1, opts.B2), "p-1"
+	} else if opts.method == methodPPlus1 {
+		return WilliamsPPlus1(m, opts.B1, opts.B2), "p+1"
+	} else if opts.method == methodECM {
+		return ECM(m, opts.B1, opts.B2, opts.curves, opts.seed), "ecm"
+	} else if opts.method == methodRho {
+		return pollardRho(m, 0), "rho"
+	} else if m.IsUint64() == true {
+		if f, name := splitSmallInt(m.Uint64()); f != 0 {
+			return big.NewInt(0).SetUint64(f), name
+		}
+		return nil, ""
+	}
+
+	return pollardRho(m, rhoPresieveIterations), "rho"
+}
+
+
+/* the primes of misc.SmallPrimes dividing n and the cofactor without them */
+func divideSmallPrimes(n *big.Int) ([]PrimeFactor, *big.Int) {
+
+	ret := []PrimeFactor{}
+
+	m := big.NewInt(0).Set(n)
+	quotient := big.NewInt(0)
+	rest := big.NewInt(0)
+	P := big.NewInt(0)
+
+	for _, p := range misc.SmallPrimes {
+
+		if P.SetInt64(p * p).Cmp(m) > 0 {
+			/* the cofactor is 1 or prime */
+			if m.Cmp(misc.One) > 0 && m.IsInt64() == true && m.Int64() <= misc.SmallPrimesMax {
+				ret = append(ret, PrimeFactor{big.NewInt(0).Set(m), 1})
+				m.SetInt64(1)
+			}
+			break
+		}
+
+		P.SetInt64(p)
+
+		exponent := 0
+		for {
+			quotient.QuoRem(m, P, rest)
+			if rest.Sign() != 0 {
+				break
+			}
+			m.Set(quotient)
+			exponent += 1
+		}
+
+		if exponent > 0 {
+			ret = append(ret, PrimeFactor{big.NewInt(p), exponent})
+		}
+	}
+
+	return ret, m
+}
+
+
+/* miller-rabin rounds on top of baillie-psw. misc.IsPrime() confirms by trial division, which takes forever
+for the cofactors of large n */
+const primalityRounds = 20
+
+
+func isProbablePrime(n *big.Int) bool {
+	return n.ProbablyPrime(primalityRounds)
+}
+
+
+/* 2^3 * 17 * 97. 1 for no factors */
+func factorizationString(factors []PrimeFactor) string {
+
+	if len(factors) == 0 {
+		return "1"
+	}
+
+	parts := make([]string, len(factors))
+	for i, factor := range factors {
+		parts[i] = factor.String()
+	}
+
+	return strings.Join(parts, " * ")
+}
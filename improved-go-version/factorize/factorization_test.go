@@ -0,0 +1,96 @@
+package main
+
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/hydroo/quadratic-sieve/misc"
+)
+
+
+func TestFactorCompletely(t *testing.T) {
+
+	nums := []string{
+			"1",
+			"2",
+			"1649",
+			"7429",
+			"13192",
+			"3481",
+			"362684905587521",
+			"577456376319",
+			"2626849055875131",
+			"2626849055875147",
+			"1000000000000000000000000000000",
+			"194822769053998839904705444189",
+			"170141183460469231731687303715884105727",
+			}
+
+	for _, num := range nums {
+
+		n, _ := big.NewInt(0).SetString(num, 10)
+
+		factors, err := FactorCompletely(n)
+		if err != nil {
+			t.Error(n, err)
+			continue
+		}
+
+		testFactorization(t, n, factors)
+	}
+
+	/* trial division, perfect power and primality check leave nothing to factorize() */
+	n, _ := big.NewInt(0).SetString("1000000007", 10)
+	n.Exp(n, big.NewInt(5), nil)
+	n.Mul(n, big.NewInt(2 * 2 * 2 * 17 * 97))
+
+	factors, err := factorCompletely(n, options{factorBaseSize: maxFactorBaseSize + 1})
+	if s := factorizationString(factors); err != nil || s != "2^3 * 17 * 97 * 1000000007^5" {
+		t.Error(n, "=", s, err)
+	}
+
+	for _, n := range []int64{0, -15} {
+		if _, err := FactorCompletely(big.NewInt(n)); err == nil {
+			t.Error("no error for", n)
+		}
+	}
+}
+
+
+func TestFactorizationString(t *testing.T) {
+
+	factors := []PrimeFactor{{big.NewInt(2), 3}, {big.NewInt(17), 1}, {big.NewInt(97), 1}}
+
+	if s := factorizationString(factors); s != "2^3 * 17 * 97" {
+		t.Error(s, "!= 2^3 * 17 * 97")
+	}
+
+	if s := factorizationString([]PrimeFactor{}); s != "1" {
+		t.Error(s, "!= 1")
+	}
+}
+
+
+/* sorted, prime, and multiplies up to n */
+func testFactorization(t *testing.T, n *big.Int, factors []PrimeFactor) {
+
+	product := big.NewInt(1)
+
+	for i, factor := range factors {
+
+		if i > 0 && factors[i-1].Prime.Cmp(factor.Prime) >= 0 {
+			t.Error(n, ": factors", factors, "are not sorted")
+		}
+
+		if factor.Exponent < 1 || factor.Prime.ProbablyPrime(20) == false {
+			t.Error(n, ":", factor, "is not a prime power")
+		}
+
+		product.Mul(product, big.NewInt(0).Exp(factor.Prime, big.NewInt(int64(factor.Exponent)), nil))
+	}
+
+	if product.Cmp(n) != 0 || (n.Cmp(misc.One) == 0 && len(factors) != 0) {
+		t.Error(n, "!=", factorizationString(factors))
+	}
+}
@@ -10,50 +10,91 @@ import (
 )
 
 
-func factorBase(n *big.Int) []*big.Int {
+/* the sieving code works on native integers, the factor base has to stay well within them */
+const maxFactorBaseSize = 1 << 20
+const maxFactorBasePrime = 1 << 31 - 1
 
-	/* calculate 'S' upper bound for the primes to collect */
-	lnn := float64(n.BitLen()) * math.Log(2)
 
-	if lnn < 1.0 {
-		/* if this is not done. if n is 1 everything explodes sqrt(<0) = NaN */
-		lnn = 1.0
+/* -1 and the first size primes p with n a square rest mod p. size 0 collects the ones up to a bound from L(n),
+which is only sensible for small n */
+func factorBase(n *big.Int, size int) ([]*big.Int, error) {
+
+	if size > maxFactorBaseSize {
+		return nil, fmt.Errorf("factor base size %d exceeds the supported %d", size, maxFactorBaseSize)
 	}
 
-	lnlnn := math.Log(lnn)
-	exp := math.Sqrt(lnn*lnlnn) * 0.5
+	var S int64
+
+	if size <= 0 {
+
+		/* calculate 'S' upper bound for the primes to collect */
+		lnn := float64(n.BitLen()) * math.Log(2)
+
+		if lnn < 1.0 {
+			/* if this is not done. if n is 1 everything explodes sqrt(<0) = NaN */
+			lnn = 1.0
+		}
+
+		lnlnn := math.Log(lnn)
+		exp := math.Sqrt(lnn*lnlnn) * 0.5
+
+		if exp > math.Log(maxFactorBasePrime) {
+			return nil, fmt.Errorf("factor base bound from L(n) for %d bits exceeds %d, give a factor base size",
+					n.BitLen(), int64(maxFactorBasePrime))
+		}
 
-	if (exp >= 43) {
-		/* this is reached when trying to factorize about 2^1500 or larger */
-		panic("factorBase(): exponent too large ... reimplement this using big ints")
+		S = int64(math.Ceil(math.Pow(math.E, exp))) // magic parameter (wikipedia)
+
+	} else {
+
+		/* about every other prime qualifies. p_k < k (ln k + ln ln k) for k >= 6 */
+		k := float64(2*size + 6)
+		S = int64(k * (math.Log(k) + math.Log(math.Log(k))))
 	}
 
-	S := int64(math.Ceil(math.Pow(math.E, exp))) // magic parameter (wikipedia)
+	for {
+		primes := factorBaseUpTo(n, S, size)
+
+		if size <= 0 || len(primes) > size {
+			return primes, nil
+		}
+
+		if S >= maxFactorBasePrime {
+			return nil, fmt.Errorf("only %d factor base primes below %d, %d requested", len(primes) - 1,
+					int64(maxFactorBasePrime), size)
+		}
+
+		S = 2*S
+		if S > maxFactorBasePrime {
+			S = maxFactorBasePrime
+		}
+	}
+}
+
+
+/* -1 and the primes p <= S with n a square rest mod p, at most size of them if size > 0 */
+func factorBaseUpTo(n *big.Int, S int64, size int) []*big.Int {
 
 	primes := make([]*big.Int, 1)
 	primes[0] = misc.MinusOne
 
-	for p := int64(2); p <= S; p += 1 {
-		if misc.IsPrimeBruteForceSmallInt(p) {
-			
-			P := big.NewInt(int64(p))
-
-			nModP := big.NewInt(0)
-			nModP.Mod(n, P)
-
-			if p == 2 || nModP.Cmp(misc.Zero) == 0 {
-				/* n is always a square rest (mod 2), and 0 is always a squarerest of 0 */
-				primes = append(primes, P)
-			} else {
-				/* euler criterium. given ggt(a,p)=1: n is square rest mod p, iff n**((p-1)/2) \equiv 1 (mod p) */
-				result := big.NewInt(0)
-				result.Exp(n, big.NewInt((p-1)/2), P)
-				result.Mod(result,P)
-
-				if result.Cmp(misc.One) == 0 {
-					primes = append(primes, P)
-				}
-			}
+	nModP := big.NewInt(0)
+
+	for _, p := range misc.Primes(S) {
+
+		if size > 0 && len(primes) > size {
+			break
+		}
+
+		P := big.NewInt(p)
+		nModP.Mod(n, P)
+
+		if p == 2 || nModP.Sign() == 0 {
+			/* n is always a square rest (mod 2), and 0 is always a squarerest of 0 */
+			primes = append(primes, P)
+		} else if misc.LegendreSmallInt(nModP.Int64(), p) == 1 {
+			/* euler criterium. given ggt(a,p)=1: n is square rest mod p, iff n**((p-1)/2) \equiv 1 (mod p) */
+			primes = append(primes, P)
 		}
 	}
 
@@ -61,58 +102,294 @@ func factorBase(n *big.Int) []*big.Int {
 }
 
 
-func sieveInterval(n *big.Int) (min, max *big.Int) {
+/* a power q = p^k of a factor base prime and the solutions of x^2 = n (mod q) */
+type primePower struct {
+	q int64
+	roots []int64
+}
+
+
+/* sieving information for one prime of the factor base */
+type sievePrime struct {
+	p int64
+	logP uint8 /* scaled, rounded log2(p) */
+	powers []primePower /* p, p^2, ... up to the largest power worth sieving with */
+}
+
+
+/* solutions of x^2 = n (mod p). one if p = 2 or p divides n, two otherwise */
+func (this sievePrime) roots() []int64 {
+	return this.powers[0].roots
+}
+
+
+func sievePrimes(n *big.Int, factorBase []*big.Int, logScale float64, maxPower int64) []sievePrime {
+
+	ret := make([]sievePrime, len(factorBase))
+
+	nModQ := big.NewInt(0)
+	Q := big.NewInt(0)
+
+	/* i = 0 (p = -1) is not sieved */
+	for i := 1; i < len(factorBase); i += 1 {
+
+		if factorBase[i].BitLen() > 31 {
+			panic("sievePrimes(): factor base prime too large")
+		}
+
+		p := factorBase[i].Int64()
+
+		nModQ.Mod(n, factorBase[i])
+		root := misc.SquareRootModPrimeSmallInt(nModQ.Int64(), p)
+
+		ret[i].p = p
+		ret[i].logP = uint8(math.Max(1.0, math.Floor(math.Log2(float64(p))*logScale + 0.5)))
+
+		if root == p - root || root == 0 {
+			ret[i].powers = []primePower{{p, []int64{root}}}
+		} else {
+			ret[i].powers = []primePower{{p, []int64{root, p - root}}}
+		}
+
+		/* each power p^k dividing d(i) adds another log(p) */
+		for q := p * p; q <= maxPower; q *= p {
+
+			nModQ.Mod(n, Q.SetInt64(q))
+
+			roots := misc.LiftSquareRootsSmallInt(nModQ.Int64(), p, ret[i].powers[len(ret[i].powers)-1].roots, q)
+
+			if len(roots) == 0 {
+				break
+			}
+
+			ret[i].powers = append(ret[i].powers, primePower{q, roots})
+		}
+	}
+
+	return ret
+}
+
+
+/* sieve values this many times log2(largest factor base prime) below log2|d(i)| are still trial divided.
+parameters.thresholdFudge overrides it */
+const sieveThresholdFudge = 1.0
+
+
+/* sieve() gives up after this many segments in a row without a new relation, for n with too few smooth c^2 - n */
+const qsMaxBarrenSegments = 1 << 12
+
+/* with no upper bound, log2|d(i)| is scaled for c(i) up to this far above sqrt(n). the barren segments stop the
+sieve long before */
+const qsMaxOffset = 1 << 48
 
-	lnn := float64(n.BitLen()) * math.Log(2)
 
-	if lnn < 1.0 {
-		/* if this is not done. if n is 1 everything explodes sqrt(<0) = NaN */
-		lnn = 1.0
+/* where one direction of the sieve is at */
+type sieveSegment struct {
+	start *big.Int /* c(i) of the first position of the segment */
+	offsets [][][]int64 /* offsets[i][k][r]: first position in the segment hit by root r of the k-th power of prime i */
+	startModP []int64 /* c(i) mod p of the first position for each prime */
+}
+
+
+/* sieves [cMin, cMax] outward from sqrt(n) in segments of segmentSize positions, one above and one below in turn,
+and stops as soon as there are wanted relations. they go to the set as soon as they are found. cMax nil is no upper
+bound, then only qsMaxBarrenSegments ends it early */
+func sieve(n *big.Int, factorBase []*big.Int, cMin, cMax *big.Int, segmentSize int64, wanted int, thresholdFudge float64,
+		relations *relationSet) {
+
+	if cMax != nil && cMax.Cmp(cMin) < 0 {
+		return
 	}
 
-	lnlnn := math.Log(lnn)
-	exp := int64(math.Ceil(math.Sqrt(lnn*lnlnn) * math.Log2(math.E)))
 
-	L := big.NewInt(0)
-	L.Exp(misc.Two, big.NewInt(exp), nil) // magic parameter (wikipedia)
+	/* log2|d(i)| = log2|t| + log2|2*sqrt(n) + t| with t = c(i) - sqrt(n). precompute sqrt(n) as a float and
+	the offset of each segment to ceil(sqrt(n)), everything else is cheap float arithmetic per position */
+	sqrtNFloat, _ := big.NewFloat(0).SetInt(n).Sqrt(big.NewFloat(0).SetInt(n)).Float64()
+	sqrtNCeil := misc.SquareRootCeil(n)
+	tCorrection := 0.0
+	if sqrtNCeil.BitLen() <= 52 {
+		/* beyond that the fractional part of sqrt(n) doesn't matter for the logarithm anymore */
+		tCorrection = float64(sqrtNCeil.Int64()) - sqrtNFloat
+	}
 
-	sqrtN := misc.SquareRootCeil(n)
+	offset := big.NewInt(0)
+	tOf := func(c *big.Int) float64 {
+		t, _ := big.NewFloat(0).SetInt(offset.Sub(c, sqrtNCeil)).Float64()
+		return t + tCorrection
+	}
 
-	sieveMin := big.NewInt(0)
-	sieveMin.Sub(sqrtN, L)
-	sieveMax := big.NewInt(0)
-	sieveMax.Add(sqrtN, L)
+	log2D := func(t float64) float64 {
+		return math.Max(0.0, math.Log2(math.Abs(t)) + math.Log2(math.Abs(2*sqrtNFloat + t)))
+	}
 
-	return sieveMin, sieveMax
+	/* scale the logarithms down if the largest d(i) would overflow a byte */
+	maxLog := log2D(qsMaxOffset)
+	if cMax != nil {
+		maxLog = log2D(tOf(cMax))
+	}
+
+	logScale := 1.0
+	if maxLog = math.Max(log2D(tOf(cMin)), maxLog); maxLog > 240 {
+		logScale = 240 / maxLog
+	}
+
+	/* powers larger than a segment hit it at most once per root, but these are exactly the
+	ones that are missing the most for small factor bases */
+	primes := sievePrimes(n, factorBase, logScale, math.MaxInt32)
+
+	/* allow for rounding errors and prime powers, which are only counted once, and a large prime */
+	fudge := math.Log2(float64(primes[len(primes)-1].p)) * thresholdFudge + relations.largePrimeBits()
+
+	/* start at ceil(sqrt(n)) if it is in the interval, at the nearest end otherwise */
+	center := big.NewInt(0).Set(sqrtNCeil)
+	if center.Cmp(cMin) < 0 {
+		center.Set(cMin)
+	} else if cMax != nil && center.Cmp(cMax) > 0 {
+		center.Add(cMax, misc.One)
+	}
+
+	up := newSieveSegment(center, primes)
+	down := newSieveSegment(center, primes)
+
+	logs := make([]uint8, segmentSize)
+	length := big.NewInt(0)
+	maxLength := big.NewInt(segmentSize)
+
+	barren := 0
+
+	for relations.count() < wanted && barren < qsMaxBarrenSegments {
+
+		upDone := cMax != nil && up.start.Cmp(cMax) > 0
+		downDone := down.start.Cmp(cMin) <= 0
+
+		if upDone == true && downDone == true {
+			break
+		}
+
+		count := relations.count()
+
+		if upDone == false {
+			/* [start, min(start + segment size - 1, cMax)] */
+			length.Set(maxLength)
+			if cMax != nil {
+				length.Sub(cMax, up.start)
+				length.Add(length, misc.One)
+				if length.Cmp(maxLength) > 0 {
+					length.Set(maxLength)
+				}
+			}
+
+			sieveSegmentOf(n, factorBase, primes, up, length.Int64(), tOf(up.start), log2D, fudge, logScale, logs, wanted, relations)
+			up.move(length.Int64(), primes)
+		}
+
+		if downDone == false && relations.count() < wanted {
+			/* [max(start - segment size, cMin), start - 1] */
+			length.Sub(down.start, cMin)
+			if length.Cmp(maxLength) > 0 {
+				length.Set(maxLength)
+			}
+
+			down.move(-length.Int64(), primes)
+			sieveSegmentOf(n, factorBase, primes, down, length.Int64(), tOf(down.start), log2D, fudge, logScale, logs, wanted, relations)
+		}
+
+		if relations.count() > count {
+			barren = 0
+		} else {
+			barren += 1
+		}
+	}
 }
 
 
-func sieve(n *big.Int, factorBase []*big.Int, cMin, cMax *big.Int) (retCis, retDis []*big.Int, retExponents [][]int) {
+func newSieveSegment(start *big.Int, primes []sievePrime) *sieveSegment {
+
+	var ret sieveSegment
+	ret.start = big.NewInt(0).Set(start)
+	ret.offsets = make([][][]int64, len(primes))
+	ret.startModP = make([]int64, len(primes))
+
+	startModQ := big.NewInt(0)
+
+	for i := 1; i < len(primes); i += 1 {
+
+		ret.offsets[i] = make([][]int64, len(primes[i].powers))
+
+		for k, power := range primes[i].powers {
+
+			q := power.q
+			startModQ.Mod(start, big.NewInt(q))
 
-	intervalBig := big.NewInt(0)
-	intervalBig.Sub(cMax, cMin)
-	intervalBig.Add(intervalBig, misc.One)
+			if k == 0 {
+				ret.startModP[i] = startModQ.Int64()
+			}
 
-	if intervalBig.BitLen() > 31 {
-		panic("fufufu sieve interval too large. code newly.")
+			ret.offsets[i][k] = make([]int64, len(power.roots))
+			for r, root := range power.roots {
+				ret.offsets[i][k][r] = (root - startModQ.Int64() + q) % q
+			}
+		}
 	}
 
-	retCis = make([]*big.Int, 0)
-	retDis = make([]*big.Int, 0)
-	retExponents = make([][]int, 0)
+	return &ret
+}
 
 
-	ci := big.NewInt(0)
-	ci.Set(cMin)
+/* moves the segment start by delta positions, forward or backward */
+func (this *sieveSegment) move(delta int64, primes []sievePrime) {
+
+	for i := 1; i < len(primes); i += 1 {
+
+		p := primes[i].p
+		this.startModP[i] = ((this.startModP[i] + delta) % p + p) % p
 
+		for k, power := range primes[i].powers {
+			for r := range power.roots {
+				this.offsets[i][k][r] = ((this.offsets[i][k][r] - delta) % power.q + power.q) % power.q
+			}
+		}
+	}
+
+	this.start.Add(this.start, big.NewInt(delta))
+}
+
+
+/* sieves the length positions from segment.start on and trial divides the promising ones until there are wanted
+relations. t is c(i) - sqrt(n) of the first position */
+func sieveSegmentOf(n *big.Int, factorBase []*big.Int, primes []sievePrime, segment *sieveSegment, length int64, t float64,
+		log2D func(float64) float64, fudge, logScale float64, logs []uint8, wanted int, relations *relationSet) {
+
+	for j := range logs {
+		logs[j] = 0
+	}
+
+	/* p^k divides d(i) iff c(i) = root (mod p^k) -> add log(p) at every p^k-th position starting at the root */
+	for i := 1; i < len(primes); i += 1 {
+		for k, power := range primes[i].powers {
+			for _, j := range segment.offsets[i][k] {
+				for ; j < length; j += power.q {
+					logs[j] += primes[i].logP
+				}
+			}
+		}
+	}
+
+	ci := big.NewInt(0)
 	di := big.NewInt(0) /* to be factorized */
 	diCopy := big.NewInt(0) /* to be factorized */
 	exponents := make([]int, len(factorBase)) /* exponents for the factors in factorbase for di */
 	rest := big.NewInt(0)
 	quotient := big.NewInt(0)
 
-	/* foreach c(i) in [cMin, cMax] */
-	for ; ci.Cmp(cMax) <= 0; ci.Add(ci, misc.One) {
+	/* foreach c(i) in the segment whose sieve value suggests that d(i) is smooth */
+	for j := int64(0); j < length && relations.count() < wanted; j += 1 {
+
+		if float64(logs[j]) < (log2D(t + float64(j)) - fudge) * logScale {
+			continue
+		}
+
+		ci.Add(segment.start, big.NewInt(j))
 
 		/* d(i) = c(i)^2 - n */
 		di.Mul(ci, ci)
@@ -130,44 +407,41 @@ func sieve(n *big.Int, factorBase []*big.Int, cMin, cMax *big.Int) (retCis, retD
 
 		for i := 1; i < len(factorBase); i += 1 {
 
-			p := factorBase[i]
-
 			exponents[i] = 0
 
+			/* only primes whose roots match c(i) divide d(i) */
+			cModP := (segment.startModP[i] + j) % primes[i].p
+			divides := false
+			for _, root := range primes[i].roots() {
+				if cModP == root {
+					divides = true
+				}
+			}
+
+			if divides == false || di.Sign() == 0 {
+				continue
+			}
+
+			p := factorBase[i]
+
 			/* repeat as long as di % p == 0 -> add 1 to the exponent for each division by p */
 			for {
-				quotient.DivMod(di, p, rest)
+				quotient.QuoRem(di, p, rest)
 
-				if rest.Cmp(misc.Zero) == 0 {
+				if rest.Sign() == 0 {
 					exponents[i] += 1
 					di.Set(quotient)
 				} else {
 					break
 				}
-
-				if quotient.Cmp(misc.Zero) == 0 {
-					break
-				}
 			}
 		}
 
 		/* if d(i) is 1, d(i) has been successfully broken down and can be represented through
-		the factor base -> save c(i) and the exponents for the prime factors in factorbase */
-		if di.Cmp(misc.One) == 0 {
-			ciCopy := big.NewInt(0)
-			ciCopy.Set(ci)
-			diCopy2 := big.NewInt(0)
-			diCopy2.Set(diCopy)
-			exponentsCopy := make([]int, len(exponents))
-			copy(exponentsCopy, exponents)
-
-			retCis = append(retCis, ciCopy)
-			retDis = append(retDis, diCopy2)
-			retExponents = append(retExponents, exponentsCopy)
-		}
+		the factor base -> save c(i) and the exponents for the prime factors in factorbase.
+		if it is a large prime, save it as a partial relation */
+		relations.add(ci, diCopy, exponents, di)
 	}
-
-	return retCis, retDis, retExponents
 }
 
 
@@ -192,6 +466,29 @@ func linearSystemFromExponents(exponents [][]int) *LinearSystem {
 }
 
 
+/* the relation matrix with only the odd exponents, as for linearSystemFromExponents() */
+func sparseMatrixFromExponents(exponents [][]int) *SparseMatrix {
+
+	if len(exponents) == 0 || len(exponents[0]) == 0 {
+		panic("exponents is not supposed to be empty")
+	}
+
+	ret := NewSparseMatrix(len(exponents[0]), len(exponents))
+
+	for i, column := range exponents {
+		rows := []int{}
+		for j, value := range column {
+			if value % 2 == 1 {
+				rows = append(rows, j)
+			}
+		}
+		ret.SetColumn(i, rows)
+	}
+
+	return ret
+}
+
+
 type AandBSquared struct {
 	a, b *big.Int
 }
@@ -239,13 +536,51 @@ func createPowerSetRecursively(currentIndex int, currentValues AandBSquared, set
 }
 
 
-func findXandY(n *big.Int, cis, dis []*big.Int, exponents [][]int) (*big.Int, *big.Int) {
+/* the relations (indices into exponents) of every dependency found by solver, the exponents as for
+linearSystemFromExponents(). filter filters the matrix first */
+func findDependencies(exponents [][]int, solver linearSolver, filter bool) [][]int {
 
-	ls := linearSystemFromExponents(exponents)
-	ls.GaussianElimination(ls)
-	ls = ls.EliminateEmptyRows()
-	ls = ls.Transpose()
-	usedCombinations := ls.MakeEmptyRows()
+	filtered, origins := exponents, [][]int(nil)
+
+	if filter == true {
+		filtered, origins = filterRelations(exponents)
+	}
+
+	var usedCombinations [][]int
+
+	if len(filtered) > 0 && len(filtered[0]) == 0 {
+		/* no prime is left, every filtered relation is a square on its own */
+		for i := range filtered {
+			usedCombinations = append(usedCombinations, []int{i})
+		}
+	} else if len(filtered) > 0 {
+
+		if solver == solverLanczos {
+			usedCombinations = blockLanczosDependencies(filtered)
+		} else if solver == solverWiedemann {
+			usedCombinations = blockWiedemannDependencies(filtered)
+		}
+
+		if len(usedCombinations) == 0 {
+			ls := linearSystemFromExponents(filtered)
+			ls.GaussianElimination(ls)
+			ls = ls.EliminateEmptyRows()
+			ls = ls.Transpose()
+			usedCombinations = ls.MakeEmptyRows()
+		}
+	}
+
+	if filter == true {
+		usedCombinations = unfilterDependencies(usedCombinations, origins)
+	}
+
+	return usedCombinations
+}
+
+
+func findXandY(n *big.Int, cis, dis []*big.Int, exponents [][]int, solver linearSolver, filter bool) (*big.Int, *big.Int) {
+
+	usedCombinations := findDependencies(exponents, solver, filter)
 
 	if len(usedCombinations) == 0 {
 		return nil, nil
@@ -306,7 +641,9 @@ func findXandY(n *big.Int, cis, dis []*big.Int, exponents [][]int) (*big.Int, *b
 		x.SetInt64(0)
 		y.SetInt64(0)
 
-		abb.b = misc.SquareRootCeil(abb.b)
+		/* the product of the d(i) of a dependency is a square of thousands of digits, newton is much faster than
+		misc.SquareRootCeil() there */
+		abb.b = big.NewInt(0).Sqrt(abb.b)
 
 		x.Add(abb.a,abb.b)
 		x.Mod(x,n)
@@ -350,26 +687,148 @@ func findXandY(n *big.Int, cis, dis []*big.Int, exponents [][]int) (*big.Int, *b
 }
 
 
-/* returns nil, nil if n cannot be factorized */
-func factorize(n *big.Int, benchmark bool) (*big.Int, *big.Int) {
+/* where the relations c(i)^2 = d(i) (mod n) come from */
+type relationSource int
+
+const (
+	sourceQS relationSource = iota /* c^2 - n outward from sqrt(n) until there are enough relations, see sieve() */
+	sourceMPQS
+	sourceSIQS
+	sourceCFRAC /* the continued fraction of sqrt(n), not sieved */
+	sourceDixon /* random squares, a slow reference */
+)
+
+
+/* how the dependencies between the relations are found */
+type linearSolver int
+
+const (
+	solverGauss linearSolver = iota /* dense gaussian elimination on LinearSystem */
+	solverLanczos /* block lanczos on the sparse matrix, falls back to gauss if it fails */
+	solverWiedemann /* block wiedemann on the sparse matrix, falls back to gauss if it fails */
+)
+
+
+/* how factorCompletely() splits composites */
+type factoringMethod int
+
+const (
+	methodQS factoringMethod = iota /* splitSmallInt() below 2^64, pollard rho for small factors, factorize() for the rest */
+	methodRho /* pollard rho only */
+	methodPMinus1 /* pollard p-1 only */
+	methodPPlus1 /* williams p+1 only */
+	methodECM /* the elliptic curve method only */
+	methodGNFS /* as methodQS, but gnfs() instead of factorize() */
+)
+
+
+/* relation count beyond the size of the factor base, to get a few dependencies. below parameterTable */
+const extraRelations = 20
+
+
+/* large primes up to this times the largest factor base prime. below parameterTable */
+const defaultLargePrimeMultiplier = 32
+
+
+/* settings from the command line */
+type options struct {
+	benchmark bool
+	source relationSource
+	largePrimeMultiplier int64 /* large primes up to this times the largest factor base prime, 0 disables them,
+			tableLargePrimeMultiplier takes it from parametersFor() */
+	doubleLargePrimes bool /* also keep partial relations with two large primes */
+	solver linearSolver
+	filter bool /* filter the relation matrix before the linear algebra */
+	multiplier int64 /* sieve k n instead of n. 0 and 1 disable it, autoMultiplier picks one */
+	method factoringMethod
+	B1, B2 int64 /* stage bounds of p-1, p+1 and ecm, 0 takes the default of the method */
+	curves int /* curves ecm tries, 0 takes ecmDefaultCurves */
+	seed int64 /* picks the curves of ecm */
+	fermatIterations int /* steps of fermat's method in front of qs and gnfs, 0 disables it */
+
+	/* overrides for parametersFor(), 0 takes the value from the table */
+	factorBaseSize int
+	blockSize int
+	thresholdFudge float64
+	extraRelations int
+}
+
+
+/* the defaults of the factor command */
+func defaultOptions() options {
+
+	var ret options
+	ret.largePrimeMultiplier = tableLargePrimeMultiplier
+	ret.multiplier = autoMultiplier
+	ret.fermatIterations = fermatPresieveIterations
+
+	return ret
+}
+
+
+/* returns nil, nil if n cannot be factorized, an error if the parameters are beyond what the sieve supports */
+func factorize(n *big.Int, opts options) (*big.Int, *big.Int, error) {
+
+	benchmark := opts.benchmark
 
 	t1 := time.Now()
 
-	factorBase := factorBase(n)
+	/* dixon's values are as large as k n, a multiplier only makes them larger */
+	if opts.source == sourceDixon {
+		if digits, maxDigits := len(n.String()), dixonParameterTable[len(dixonParameterTable)-1].digits; digits > maxDigits {
+			return nil, nil, fmt.Errorf("%d digits are beyond the %d of dixon's random squares", digits, maxDigits)
+		}
+		opts.multiplier = 1
+	}
+
+	k := opts.multiplier
+	if k == autoMultiplier {
+		k = knuthSchroeppel(n)
+	} else if k < 1 {
+		k = 1
+	}
+
+	/* everything up to the relations works on k n. c^2 = d (mod k n) holds mod n, too */
+	kn := big.NewInt(0)
+	kn.Mul(n, big.NewInt(k))
+
+	params := parametersFor(kn, opts)
+
+	factorBase, err := factorBase(kn, params.factorBaseSize)
+	if err != nil {
+		return nil, nil, err
+	}
+	params.factorBaseSize = len(factorBase) - 1
 
 	t2 := time.Now()
 
-	min, max := sieveInterval(n)
+	relations := newRelationSet(kn, factorBase, params.largePrimeMultiplier, opts.doubleLargePrimes)
 
 	t3 := time.Now()
 
-	cis, dis, exponents := sieve(n, factorBase, min, max)
+	wanted := len(factorBase) + params.extraRelations
+
+	if opts.source == sourceMPQS && kn.BitLen() >= mpqsMinBits {
+		mpqs(kn, factorBase, params.blockSize, wanted, params.thresholdFudge, relations)
+	} else if opts.source == sourceSIQS && kn.BitLen() >= mpqsMinBits {
+		siqs(kn, factorBase, params.blockSize, wanted, params.thresholdFudge, relations)
+	} else if opts.source == sourceCFRAC {
+		cfrac(kn, factorBase, wanted, relations)
+	} else if opts.source == sourceDixon {
+		dixon(kn, factorBase, wanted, relations)
+	} else {
+		/* -c gives the same c^2 - n as c */
+		sieve(kn, factorBase, misc.One, nil, 2*int64(params.blockSize), wanted, params.thresholdFudge, relations)
+	}
+
+	cis, dis, exponents := relations.cis, relations.dis, relations.exponents
 
 	if len(cis) > 0 {
 
 		t4 := time.Now()
 
-		x, y := findXandY(n, cis, dis, exponents)
+		x, y := findXandY(n, cis, dis, exponents, opts.solver, opts.filter)
+		x, y = factorsOfN(n, x, y)
 
 		t5 := time.Now()
 
@@ -379,32 +838,33 @@ func factorize(n *big.Int, benchmark bool) (*big.Int, *big.Int) {
 			x, y = y, x
 		}
 
+		/* one line per split with the effective parameters, the factor command prints the complete factorization
+		after them */
 		if x != nil && y != nil {
 			fmt.Print(n, " + ", x, y)
-			if benchmark == true {
-				fmt.Print(" wall ", nanoSecondsToString(t5.Sub(t1).Nanoseconds()),
-				" sieve ", nanoSecondsToString(t4.Sub(t3).Nanoseconds()),
-				" combing ", nanoSecondsToString(t5.Sub(t4).Nanoseconds()))
-			}
 		} else {
 			fmt.Print(n, " - - -")
-			if benchmark == true {
-				fmt.Print(" wall ", nanoSecondsToString(t5.Sub(t1).Nanoseconds()),
-				" sieve ", nanoSecondsToString(t4.Sub(t3).Nanoseconds()),
-				" combing ", nanoSecondsToString(t5.Sub(t4).Nanoseconds()))
-			}
 		}
+		if benchmark == true {
+			fmt.Print(" wall ", nanoSecondsToString(t5.Sub(t1).Nanoseconds()),
+			" sieve ", nanoSecondsToString(t4.Sub(t3).Nanoseconds()),
+			" combing ", nanoSecondsToString(t5.Sub(t4).Nanoseconds()))
+		}
+		fmt.Print(" fulls ", relations.fulls, " partials ", relations.partialCount,
+		" partial-partials ", relations.partialPartialCount, " cycles ", relations.cycles,
+		" multiplier ", k, " ", params)
 		fmt.Println()
 
 		//fmt.Println("n:", n,  "sieve interval: [", min, "..", max, "] =", max.Int64() - min.Int64(), "factorbase:", factorBase, "c(i)", cis, "exponents:", exponents, "result:", x, "*", y)
 
-		return x, y
+		return x, y, nil
 
 	} else {
-		fmt.Println(n, "- - -")
+		fmt.Println(n, "- - -", "fulls", relations.fulls, "partials", relations.partialCount,
+		"partial-partials", relations.partialPartialCount, "cycles", relations.cycles, "multiplier", k, params)
 		/* return nil, nil */
 	}
 
-	return nil, nil
+	return nil, nil, nil
 }
 
@@ -3,8 +3,11 @@ package main
 
 import (
 	//"fmt"
+	"math"
 	"math/big"
 	"testing"
+
+	"github.com/hydroo/quadratic-sieve/misc"
 )
 
 
@@ -18,13 +21,16 @@ func TestFactorize(t *testing.T) {
 	nums := []Number{
 			{1649, 17, 97},
 			//{7429, 19, 391},
-			//{7429, 17, 437},
-			{7429, 23, 323},
+			{7429, 17, 437},
+			//{7429, 23, 323},
 			{40198364677, 599, 67109123},
 			{18923626564873, 2203, 8589934891},
 			//{362684905587521, 66847, 5425597343},
-			{362684905587521, 1133, 320110243237},
-			{2626849055875131, 4549, 577456376319},
+			{362684905587521, 6077, 59681570773},
+			//{362684905587521, 1133, 320110243237},
+			//{2626849055875131, 4549, 577456376319},
+			//{2626849055875131, 122823, 21387273197},
+			{2626849055875131, 40941, 64161819591},
 			//{2626849055875147, 25025783, 104965709},
 			{2626849055875147, 128477, 20446064711},
 			}
@@ -35,7 +41,10 @@ func TestFactorize(t *testing.T) {
 
 	for _, num := range nums {
 
-		x, y := factorize(big.NewInt(num.n), false)
+		x, y, err := factorize(big.NewInt(num.n), options{})
+		if err != nil {
+			t.Fatal(err)
+		}
 
 		if x.Cmp(y) > 0 {
 			x, y = y, x
@@ -52,3 +61,261 @@ func TestFactorize(t *testing.T) {
 	}
 }
 
+
+
+func TestFactorizeMPQS(t *testing.T) {
+
+	nums := []string{
+			"40198364677",
+			"2626849055875147",
+			"194822769053998839904705444189",
+			"46593138770636359115713819079909141",
+			}
+
+	testFactorizeSplits(t, nums, options{source: sourceMPQS})
+}
+
+
+func TestFactorizeSIQS(t *testing.T) {
+
+	nums := []string{
+			"40198364677",
+			"2626849055875147",
+			"194822769053998839904705444189",
+			"6734319982431950692508617574486143524749",
+			}
+
+	testFactorizeSplits(t, nums, options{source: sourceSIQS})
+}
+
+
+func TestLargePrimeRelations(t *testing.T) {
+
+	n, _ := big.NewInt(0).SetString("6734319982431950692508617574486143524749", 10)
+
+	factorBase, _ := factorBase(n, 0)
+	relations := newRelationSet(n, factorBase, 32, false)
+
+	siqs(n, factorBase, mpqsHalfWindow, len(factorBase) + extraRelations, sieveThresholdFudge, relations)
+
+	if relations.cycles == 0 {
+		t.Error("no partial relations have been combined. fulls", relations.fulls, "partials", relations.partialCount)
+	}
+
+	testRelations(t, n, factorBase, relations)
+
+	testFactorizeSplits(t, []string{n.String()}, options{source: sourceSIQS, largePrimeMultiplier: 32})
+}
+
+
+func TestDoubleLargePrimeRelations(t *testing.T) {
+
+	n, _ := big.NewInt(0).SetString("6734319982431950692508617574486143524749", 10)
+
+	factorBase, _ := factorBase(n, 0)
+	relations := newRelationSet(n, factorBase, 32, true)
+
+	siqs(n, factorBase, mpqsHalfWindow, len(factorBase) + extraRelations, sieveThresholdFudge, relations)
+
+	if relations.partialPartialCount == 0 || relations.cycles == 0 {
+		t.Error("no cycles with partial-partial relations. fulls", relations.fulls, "partials", relations.partialCount,
+				"partial-partials", relations.partialPartialCount, "cycles", relations.cycles)
+	}
+
+	testRelations(t, n, factorBase, relations)
+
+	testFactorizeSplits(t, []string{n.String()}, options{source: sourceSIQS, largePrimeMultiplier: 32, doubleLargePrimes: true})
+}
+
+
+/* c(i)^2 = d(i) (mod n) and d(i) is the factor base part times the square of the large primes */
+func testRelations(t *testing.T, n *big.Int, factorBase []*big.Int, relations *relationSet) {
+
+	rest := big.NewInt(0)
+	remainder := big.NewInt(0)
+	product := big.NewInt(0)
+
+	for i, ci := range relations.cis {
+
+		rest.Mul(ci, ci)
+		rest.Sub(rest, relations.dis[i])
+		rest.Mod(rest, n)
+
+		if rest.Sign() != 0 {
+			t.Error("c(i)^2 != d(i) (mod n) for c(i) =", ci, "d(i) =", relations.dis[i])
+			continue
+		}
+
+		product.SetInt64(1)
+		for j, e := range relations.exponents[i] {
+			for k := 0; k < e; k += 1 {
+				product.Mul(product, factorBase[j])
+			}
+		}
+
+		rest.QuoRem(relations.dis[i], product, remainder)
+
+		if remainder.Sign() != 0 || rest.Sign() < 0 || big.NewInt(0).Exp(big.NewInt(0).Sqrt(rest), misc.Two, nil).Cmp(rest) != 0 {
+			t.Error("d(i) =", relations.dis[i], "doesn't match the exponents", relations.exponents[i])
+		}
+	}
+}
+
+
+/* the split found depends on the relations, so only check that it is a non-trivial one */
+func testFactorizeSplits(t *testing.T, nums []string, opts options) {
+
+	xTimesY := big.NewInt(0)
+
+	for _, s := range nums {
+
+		n, _ := big.NewInt(0).SetString(s, 10)
+
+		x, y, err := factorize(n, opts)
+
+		if err != nil {
+			t.Error(n, err)
+			continue
+		} else if x == nil || y == nil {
+			t.Error(n, "could not be factorized")
+			continue
+		}
+
+		xTimesY.Mul(x, y)
+
+		if xTimesY.Cmp(n) != 0 || x.Cmp(misc.One) == 0 || y.Cmp(misc.One) == 0 {
+			t.Error(n, "!=", x, "*", y)
+		}
+	}
+}
+
+
+func TestKnuthSchroeppel(t *testing.T) {
+
+	nums := []string{
+		"194822769053998839904705444189",
+		"46593138770636359115713819079909141",
+		"6734319982431950692508617574486143524749",
+	}
+
+	for _, num := range nums {
+
+		n, _ := big.NewInt(0).SetString(num, 10)
+		k := knuthSchroeppel(n)
+
+		for _, m := range multipliers {
+			if knuthSchroeppelScore(n, m) > knuthSchroeppelScore(n, k) {
+				t.Error("multiplier", m, "scores better than", k, "for", n)
+			}
+		}
+	}
+
+	for _, source := range []relationSource{sourceQS, sourceSIQS} {
+		testFactorizeSplits(t, nums[:1], options{source: source, multiplier: autoMultiplier})
+	}
+
+	testFactorizeSplits(t, nums, options{source: sourceSIQS, multiplier: 3})
+}
+
+
+func TestFactorBase(t *testing.T) {
+
+	huge := big.NewInt(0).Lsh(misc.One, 1600)
+	huge.Add(huge, misc.One)
+
+	for _, size := range []int{1, 100, 5000} {
+
+		primes, err := factorBase(huge, size)
+
+		if err != nil || len(primes) != size + 1 {
+			t.Error("factor base of size", size, "has", len(primes) - 1, "primes, error", err)
+			continue
+		}
+
+		for _, p := range primes[1:] {
+			rest := big.NewInt(0).Mod(huge, p)
+			if p.Cmp(misc.Two) != 0 && rest.Sign() != 0 && misc.Legendre(rest, p) != 1 {
+				t.Error(huge, "is no square rest mod", p)
+			}
+		}
+	}
+
+	if _, err := factorBase(huge, 0); err == nil {
+		t.Error("no error for a factor base bound from L(n) at", huge.BitLen(), "bits")
+	}
+
+	if _, err := factorBase(huge, maxFactorBaseSize + 1); err == nil {
+		t.Error("no error for a factor base larger than", maxFactorBaseSize)
+	}
+
+	if _, _, err := factorize(huge, options{factorBaseSize: maxFactorBaseSize + 1}); err == nil {
+		t.Error("factorize() did not pass on the error")
+	}
+}
+
+
+/* relations across segment borders are the same as from sieving the parts on their own */
+func TestSegmentedSieve(t *testing.T) {
+
+	n, _ := big.NewInt(0).SetString("194822769053998839904705444189", 10)
+
+	factorBase, _ := factorBase(n, 200)
+
+	sqrtN := misc.SquareRootCeil(n)
+
+	const segment = 1 << 16
+
+	cMin := big.NewInt(0).Sub(sqrtN, big.NewInt(3*segment + 1234))
+	cMid := big.NewInt(0).Add(sqrtN, big.NewInt(segment / 2 + 7))
+	cMax := big.NewInt(0).Add(sqrtN, big.NewInt(2*segment + 4321))
+
+	whole := newRelationSet(n, factorBase, 0, false)
+	sieve(n, factorBase, cMin, cMax, segment, math.MaxInt32, sieveThresholdFudge, whole)
+
+	parts := newRelationSet(n, factorBase, 0, false)
+	sieve(n, factorBase, cMin, cMid, segment, math.MaxInt32, sieveThresholdFudge, parts)
+	sieve(n, factorBase, big.NewInt(0).Add(cMid, misc.One), cMax, segment, math.MaxInt32, sieveThresholdFudge, parts)
+
+	if len(whole.cis) == 0 || len(whole.cis) != len(parts.cis) {
+		t.Error(len(whole.cis), "relations from the whole interval,", len(parts.cis), "from its parts")
+	}
+
+	testRelations(t, n, factorBase, whole)
+
+	/* far away from sqrt(n), beyond what fits into 31 bits */
+	far := big.NewInt(0).Add(sqrtN, big.NewInt(1 << 40))
+	relations := newRelationSet(n, factorBase, 0, false)
+	sieve(n, factorBase, far, big.NewInt(0).Add(far, big.NewInt(segment)), segment, math.MaxInt32, sieveThresholdFudge, relations)
+
+	testRelations(t, n, factorBase, relations)
+}
+
+
+/* without an upper bound, sieving stops once there are enough relations */
+func TestSieveUntilWanted(t *testing.T) {
+
+	n, _ := big.NewInt(0).SetString("194822769053998839904705444189", 10)
+
+	factorBase, _ := factorBase(n, 400)
+
+	/* no large primes, the c(i) of combined partials are products */
+	relations := newRelationSet(n, factorBase, 0, false)
+	wanted := len(factorBase) + 30
+
+	sieve(n, factorBase, misc.One, nil, 1 << 16, wanted, sieveThresholdFudge, relations)
+
+	if relations.count() != wanted {
+		t.Error(relations.count(), "relations instead of", wanted)
+	}
+
+	/* only a few segments around sqrt(n) have been sieved */
+	distance := big.NewInt(0)
+	sqrtN := misc.SquareRootCeil(n)
+	for _, ci := range relations.cis {
+		if distance.Sub(ci, sqrtN).Abs(distance).Cmp(big.NewInt(1 << 30)) > 0 {
+			t.Error(ci, "is far from sqrt(n)", sqrtN)
+		}
+	}
+
+	testRelations(t, n, factorBase, relations)
+}
@@ -0,0 +1,66 @@
+package main
+
+/* fermat's difference of squares.
+ *
+ * n = p q = a^2 - b^2 with a = (p + q) / 2 and b = (q - p) / 2. a runs up from ceil(sqrt(n)) until a^2 - n
+ * is a square b^2. that takes about (q - p)^2 / (8 sqrt(n)) steps, which is nothing for p and q close together
+ * and hopeless otherwise. so it is only run for a bounded number of steps.
+ *
+ * a^2 - n is kept up to date by adding 2a + 1, and so is its residue mod misc.SquareResidueModulus. only
+ * the few candidates that pass misc.IsSquareResidue() need a square root. */
+
+import (
+	"math/big"
+
+	"github.com/hydroo/quadratic-sieve/misc"
+)
+
+
+/* steps in front of pollard rho and the quadratic sieve, enough for |p - q| up to about 2^9 n^(1/4) */
+const fermatPresieveIterations = 1 << 16
+
+
+/* a non-trivial factor of the odd composite n or nil after maxIterations steps */
+func Fermat(n *big.Int, maxIterations int) *big.Int {
+
+	if n.Bit(0) == 0 {
+		if n.Cmp(misc.Two) > 0 {
+			return big.NewInt(2)
+		}
+		return nil
+	}
+
+	const M = misc.SquareResidueModulus
+	Mbig := big.NewInt(M)
+
+	a := misc.SquareRootCeil(n)
+
+	/* bb = a^2 - n, r = bb mod M, s = 2a + 1 mod M */
+	bb := big.NewInt(0).Mul(a, a)
+	bb.Sub(bb, n)
+
+	t := big.NewInt(0)
+	r := t.Mod(bb, Mbig).Uint64()
+	s := t.Mod(t.Lsh(a, 1).Add(t, misc.One), Mbig).Uint64()
+
+	for i := 0; i < maxIterations; i += 1 {
+
+		if misc.IsSquareResidue(r) == true {
+			if b, ok := misc.IsSquare(bb); ok == true {
+				/* p = a - b. if it is 1, n is prime: n = (n + 1)^2 / 4 - (n - 1)^2 / 4 */
+				return nonTrivialFactor(b.Sub(a, b), n)
+			}
+		}
+
+		/* (a + 1)^2 - n = a^2 - n + 2a + 1 */
+		t.Lsh(a, 1)
+		bb.Add(bb, t)
+		bb.Add(bb, misc.One)
+		a.Add(a, misc.One)
+
+		r = (r + s) % M
+		s = (s + 2) % M
+	}
+
+	return nil
+}
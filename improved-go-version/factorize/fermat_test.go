@@ -0,0 +1,62 @@
+package main
+
+
+import (
+	"math/big"
+	"testing"
+)
+
+
+func TestFermat(t *testing.T) {
+
+	/* p = the next prime after 2^100, q after p + 2^57 and p + 2^62. fermat needs about (q - p)^2 / (8 sqrt(n))
+	steps, 2^11 and 2^21 */
+	p, _ := big.NewInt(0).SetString("1267650600228229401496703205653", 10)
+	near, _ := big.NewInt(0).SetString("1267650600228373516684779061549", 10)
+	far, _ := big.NewInt(0).SetString("1267650600232841087515130593921", 10)
+
+	n := big.NewInt(0).Mul(p, near)
+
+	if f := Fermat(n, fermatPresieveIterations); f == nil || f.Cmp(p) != 0 {
+		t.Error("Fermat(", n, ") =", f, "instead of", p)
+	}
+
+	if f := Fermat(n, 1 << 8); f != nil {
+		t.Error("Fermat(", n, ", 2^8) found", f, "beyond its bound")
+	}
+
+	/* the quadratic sieve would take a while for the 61 digits */
+	factors, err := FactorCompletely(n)
+	if err != nil {
+		t.Error(n, err)
+	} else {
+		testFactorization(t, n, factors)
+	}
+
+	/* only in front of the sieves, p-1 with tiny bounds finds nothing on its own */
+	opts := defaultOptions()
+	opts.method = methodPMinus1
+	opts.B1, opts.B2 = 10, 100
+
+	if x, y, _ := split(n, opts); x != nil || y != nil {
+		t.Error("split(", n, ") by p-1 with B1 = 10 found", x, y)
+	}
+
+	n.Mul(p, far)
+
+	if f := Fermat(n, fermatPresieveIterations); f != nil {
+		t.Error("Fermat(", n, ") found", f, "beyond its bound")
+	}
+
+	/* odd squares and small numbers */
+	for _, m := range []int64{9, 15, 1000003 * 1000003, 1000003 * 1000033} {
+		if f := Fermat(big.NewInt(m), fermatPresieveIterations); f == nil || m % f.Int64() != 0 || f.Int64() == 1 {
+			t.Error("Fermat(", m, ") =", f)
+		}
+	}
+
+	/* a prime is n = ((n + 1) / 2)^2 - ((n - 1) / 2)^2 */
+	if f := Fermat(big.NewInt(1000003), 1 << 20); f != nil {
+		t.Error("Fermat( 1000003 ) found", f, "for a prime")
+	}
+}
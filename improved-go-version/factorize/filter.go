@@ -0,0 +1,365 @@
+package main
+
+/* filtering the relation matrix before the linear algebra.
+ *
+ * only the parity of the exponents matters, so a relation is the set of factor base indizes with odd
+ * exponent. duplicates are dropped, relations with a prime no other relation has (singletons) can never
+ * be part of a dependency, and cliques (relations connected through primes occurring twice) are pruned
+ * while there are more relations than needed. structured gaussian elimination finally merges the
+ * relations of light primes: the lightest one is added to all others and dropped, together with the prime.
+ *
+ * every filtered relation remembers the original relations it is the sum of (origins), dependencies
+ * between filtered relations map back to dependencies between the original ones. */
+
+import (
+	"fmt"
+	"sort"
+)
+
+
+/* relations beyond the number of primes that survive clique removal */
+const filterExcess = 64
+
+/* primes occurring in up to this many relations are merged away */
+const filterMergeWeight = 8
+
+/* no merge may make a relation heavier than this */
+const filterMaxRelationWeight = 64
+
+
+type filterRelation struct {
+	primes []int /* sorted */
+	origins []int /* sorted */
+	removed bool
+}
+
+
+/* the filtered relation matrix (exponents 0 or 1 over the surviving primes) and per filtered relation
+the original ones it is made of */
+func filterRelations(exponents [][]int) ([][]int, [][]int) {
+
+	if len(exponents) == 0 || len(exponents[0]) == 0 {
+		panic("exponents is not supposed to be empty")
+	}
+
+	primeCount := len(exponents[0])
+
+	relations := []*filterRelation{}
+	seen := make(map[string]bool)
+
+	for i, column := range exponents {
+
+		primes := []int{}
+		nonzero := []int{}
+		for j, value := range column {
+			if value % 2 == 1 {
+				primes = append(primes, j)
+			}
+			if value != 0 {
+				nonzero = append(nonzero, j, value)
+			}
+		}
+
+		key := fmt.Sprint(nonzero)
+		if seen[key] == true {
+			continue
+		}
+		seen[key] = true
+
+		relations = append(relations, &filterRelation{primes, []int{i}, false})
+	}
+
+	for {
+		before := len(relations)
+
+		relations = removeSingletons(relations, primeCount)
+		relations = removeCliques(relations, primeCount)
+		relations = removeSingletons(relations, primeCount)
+		relations = mergeRelations(relations, primeCount)
+
+		if len(relations) == before {
+			break
+		}
+	}
+
+	/* renumber the remaining primes */
+	weights := primeWeights(relations, primeCount)
+	index := make([]int, primeCount)
+	rowCount := 0
+	for j, weight := range weights {
+		if weight > 0 {
+			index[j] = rowCount
+			rowCount += 1
+		}
+	}
+
+	filtered := make([][]int, len(relations))
+	origins := make([][]int, len(relations))
+
+	for i, relation := range relations {
+		filtered[i] = make([]int, rowCount)
+		for _, j := range relation.primes {
+			filtered[i][index[j]] = 1
+		}
+		origins[i] = relation.origins
+	}
+
+	return filtered, origins
+}
+
+
+/* maps dependencies between filtered relations to ones between the original relations */
+func unfilterDependencies(dependencies [][]int, origins [][]int) [][]int {
+
+	ret := [][]int{}
+
+	for _, indexSet := range dependencies {
+
+		combined := []int{}
+		for _, i := range indexSet {
+			combined = symmetricDifference(combined, origins[i])
+		}
+
+		if len(combined) > 0 {
+			ret = append(ret, combined)
+		}
+	}
+
+	return ret
+}
+
+
+func primeWeights(relations []*filterRelation, primeCount int) []int {
+
+	weights := make([]int, primeCount)
+
+	for _, relation := range relations {
+		for _, j := range relation.primes {
+			weights[j] += 1
+		}
+	}
+
+	return weights
+}
+
+
+func removeSingletons(relations []*filterRelation, primeCount int) []*filterRelation {
+
+	for {
+		weights := primeWeights(relations, primeCount)
+
+		kept := []*filterRelation{}
+
+		for _, relation := range relations {
+			singleton := false
+			for _, j := range relation.primes {
+				if weights[j] == 1 {
+					singleton = true
+					break
+				}
+			}
+			if singleton == false {
+				kept = append(kept, relation)
+			}
+		}
+
+		if len(kept) == len(relations) {
+			return kept
+		}
+
+		relations = kept
+	}
+}
+
+
+/* removes the largest cliques as long as there are more than filterExcess relations beyond the primes */
+func removeCliques(relations []*filterRelation, primeCount int) []*filterRelation {
+
+	weights := primeWeights(relations, primeCount)
+
+	primes := 0
+	for _, weight := range weights {
+		if weight > 0 {
+			primes += 1
+		}
+	}
+
+	excess := len(relations) - primes - filterExcess
+	if excess <= 0 {
+		return relations
+	}
+
+	/* union-find over the relations, connected through primes of weight 2 */
+	parent := make([]int, len(relations))
+	for i := range parent {
+		parent[i] = i
+	}
+
+	var find func(i int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+
+	firstOccurrence := make(map[int]int)
+
+	for i, relation := range relations {
+		for _, j := range relation.primes {
+			if weights[j] != 2 {
+				continue
+			}
+			if other, ok := firstOccurrence[j]; ok == true {
+				parent[find(i)] = find(other)
+			} else {
+				firstOccurrence[j] = i
+			}
+		}
+	}
+
+	cliques := make(map[int][]int)
+	for i := range relations {
+		cliques[find(i)] = append(cliques[find(i)], i)
+	}
+
+	sorted := [][]int{}
+	for _, clique := range cliques {
+		sorted = append(sorted, clique)
+	}
+	sort.Slice(sorted, func(a, b int) bool {
+		if len(sorted[a]) != len(sorted[b]) {
+			return len(sorted[a]) > len(sorted[b])
+		}
+		return sorted[a][0] < sorted[b][0]
+	})
+
+	for _, clique := range sorted {
+		if len(clique) > excess {
+			continue
+		}
+		for _, i := range clique {
+			relations[i].removed = true
+		}
+		excess -= len(clique)
+	}
+
+	kept := []*filterRelation{}
+	for _, relation := range relations {
+		if relation.removed == false {
+			kept = append(kept, relation)
+		}
+	}
+
+	return kept
+}
+
+
+/* structured gaussian elimination. primes occurring in few relations are eliminated by adding the
+lightest of these relations to the others and dropping it */
+func mergeRelations(relations []*filterRelation, primeCount int) []*filterRelation {
+
+	/* prime -> relations containing it */
+	occurrences := make([]map[int]bool, primeCount)
+	for j := range occurrences {
+		occurrences[j] = make(map[int]bool)
+	}
+
+	for i, relation := range relations {
+		for _, j := range relation.primes {
+			occurrences[j][i] = true
+		}
+	}
+
+	for weight := 2; weight <= filterMergeWeight; weight += 1 {
+		for j := 0; j < primeCount; j += 1 {
+
+			if len(occurrences[j]) < 2 || len(occurrences[j]) > weight {
+				continue
+			}
+
+			ids := []int{}
+			for i := range occurrences[j] {
+				ids = append(ids, i)
+			}
+			sort.Ints(ids)
+
+			pivot := ids[0]
+			for _, i := range ids {
+				if len(relations[i].primes) < len(relations[pivot].primes) {
+					pivot = i
+				}
+			}
+
+			tooHeavy := false
+			for _, i := range ids {
+				if i != pivot && len(relations[i].primes) + len(relations[pivot].primes) - 2 > filterMaxRelationWeight {
+					tooHeavy = true
+				}
+			}
+
+			if tooHeavy == true {
+				continue
+			}
+
+			for _, i := range ids {
+
+				if i == pivot {
+					continue
+				}
+
+				for _, k := range relations[pivot].primes {
+					if occurrences[k][i] == true {
+						delete(occurrences[k], i)
+					} else {
+						occurrences[k][i] = true
+					}
+				}
+
+				relations[i].primes = symmetricDifference(relations[i].primes, relations[pivot].primes)
+				relations[i].origins = symmetricDifference(relations[i].origins, relations[pivot].origins)
+			}
+
+			for _, k := range relations[pivot].primes {
+				delete(occurrences[k], pivot)
+			}
+
+			relations[pivot].removed = true
+		}
+	}
+
+	kept := []*filterRelation{}
+	for _, relation := range relations {
+		if relation.removed == false {
+			kept = append(kept, relation)
+		}
+	}
+
+	return kept
+}
+
+
+/* of two sorted slices */
+func symmetricDifference(a, b []int) []int {
+
+	ret := make([]int, 0, len(a) + len(b))
+
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		if a[i] < b[j] {
+			ret = append(ret, a[i])
+			i += 1
+		} else if a[i] > b[j] {
+			ret = append(ret, b[j])
+			j += 1
+		} else {
+			i += 1
+			j += 1
+		}
+	}
+
+	ret = append(ret, a[i:]...)
+	ret = append(ret, b[j:]...)
+
+	return ret
+}
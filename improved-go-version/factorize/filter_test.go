@@ -0,0 +1,102 @@
+package main
+
+
+import (
+	"math/rand"
+	"testing"
+)
+
+
+func TestFilterRelations(t *testing.T) {
+
+	rng := rand.New(rand.NewSource(5678))
+
+	for _, size := range []struct{ rows, columns int }{{200, 260}, {1000, 1100}, {3000, 3200}} {
+
+		exponents := randomSparseExponents(rng, size.rows, size.columns)
+
+		/* a few duplicates */
+		for i := 0; i < 10; i += 1 {
+			duplicate := make([]int, size.rows)
+			copy(duplicate, exponents[i])
+			exponents = append(exponents, duplicate)
+		}
+
+		filtered, origins := filterRelations(exponents)
+
+		if len(filtered) == 0 || len(filtered) > size.columns * 85 / 100 {
+			t.Error(size.rows, "x", size.columns, ": filtering left", len(filtered), "relations")
+			continue
+		}
+
+		if len(filtered) <= len(filtered[0]) {
+			t.Error(size.rows, "x", size.columns, ": filtering left", len(filtered), "relations for", len(filtered[0]), "primes")
+		}
+
+		for _, relationOrigins := range origins {
+			for _, i := range relationOrigins {
+				if i >= size.columns {
+					t.Error("duplicate", i, "has not been removed")
+				}
+			}
+		}
+
+		ls := linearSystemFromExponents(filtered)
+		ls.GaussianElimination(ls)
+		ls = ls.EliminateEmptyRows()
+		ls = ls.Transpose()
+
+		dependencies := unfilterDependencies(ls.MakeEmptyRows(), origins)
+
+		if len(dependencies) == 0 {
+			t.Error(size.rows, "x", size.columns, ": no dependencies left after filtering")
+		}
+
+		testDependencies(t, exponents, dependencies)
+	}
+}
+
+
+func TestSymmetricDifference(t *testing.T) {
+
+	type Test struct {
+		a, b, expect []int
+	}
+
+	tests := []Test{
+		{[]int{}, []int{}, []int{}},
+		{[]int{1, 3, 5}, []int{}, []int{1, 3, 5}},
+		{[]int{1, 3, 5}, []int{3}, []int{1, 5}},
+		{[]int{1, 3, 5}, []int{0, 2, 5, 7}, []int{0, 1, 2, 3, 7}},
+	}
+
+	for _, test := range tests {
+
+		result := symmetricDifference(test.a, test.b)
+
+		if len(result) != len(test.expect) {
+			t.Error(test.a, "^", test.b, "should be", test.expect, "not", result)
+			continue
+		}
+
+		for i := range result {
+			if result[i] != test.expect[i] {
+				t.Error(test.a, "^", test.b, "should be", test.expect, "not", result)
+				break
+			}
+		}
+	}
+}
+
+
+func TestFactorizeFiltered(t *testing.T) {
+
+	nums := []string{
+		"194822769053998839904705444189",
+		"6734319982431950692508617574486143524749",
+	}
+
+	for _, solver := range []linearSolver{solverGauss, solverLanczos, solverWiedemann} {
+		testFactorizeSplits(t, nums, options{source: sourceSIQS, largePrimeMultiplier: 32, solver: solver, filter: true})
+	}
+}
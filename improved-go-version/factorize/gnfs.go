@@ -0,0 +1,524 @@
+package main
+
+/* the general number field sieve.
+ *
+ * a monic f of degree d with f(m) = n maps Z[alpha], alpha a root of f, onto Z/n by alpha -> m. a set of pairs
+ * (a, b) with prod (a - b m) a square in Z and prod (a - b alpha) a square in Z[alpha] gives x^2 = y^2 (mod n),
+ * the same congruence the quadratic sieve looks for, with y the image of the algebraic square root.
+ *
+ * a - b m has to be smooth over the rational factor base, its norm b^d f(a/b) over the algebraic one: pairs
+ * (p, r) with f(r) = 0 (mod p), the prime ideals of degree one. for coprime a and b, p divides the norm exactly
+ * when a = b r (mod p) for one of the r. even exponents on both sides make both products squares up to units
+ * and the class group, quadratic characters (a - b s | q) for primes q beyond the factor base rule out most of
+ * the rest. the exponents and characters go through findDependencies() like the relations of the quadratic
+ * sieve.
+ *
+ * the algebraic square root is couveignes' (gnfssqrt.go), which needs the odd degree. the rest is a first version,
+ * much slower than the quadratic sieve, and gnfsParameterTable ends at the largest n it was run on. beyond that
+ * it lacks:
+ *   - base-m polynomials of odd degree, no search for better ones
+ *   - a line sieve over a in [-A, A) for b = 1, 2, ..., full relations only, no lattice sieve, no large primes */
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"time"
+
+	"github.com/hydroo/quadratic-sieve/misc"
+)
+
+
+/* quadratic characters, each fails to catch a non-square with probability 1/2 */
+const gnfsCharacters = 32
+
+/* relations beyond the columns of the matrix */
+const gnfsExtraRelations = 32
+
+/* the primes for the algebraic square root are searched from here on */
+const gnfsInertPrimeStart = 1 << 20
+
+
+type gnfsParameters struct {
+	degree int /* of the polynomial, odd */
+	rationalBound int64 /* rational factor base primes up to this */
+	algebraicBound int64 /* algebraic factor base primes up to this */
+	halfWidth int64 /* A, a in [-A, A) */
+	maxB int64 /* lines b = 1 .. maxB are sieved at most */
+	thresholdFudge float64 /* sieve values this many times log2(largest factor base prime) below log2|norm| are trial divided */
+}
+
+
+/* by decimal digits of n. the first row with at least as many digits applies, gnfs() refuses n beyond the last */
+var gnfsParameterTable = []struct {
+	digits int
+	params gnfsParameters
+}{
+	{20, gnfsParameters{3, 1 << 12, 1 << 12, 1 << 12, 1 << 12, 1.5}},
+	{22, gnfsParameters{3, 1 << 13, 1 << 13, 1 << 13, 1 << 12, 1.6}},
+	{25, gnfsParameters{3, 1 << 13, 1 << 14, 1 << 14, 1 << 12, 1.7}},
+	{30, gnfsParameters{3, 1 << 14, 1 << 15, 1 << 15, 1 << 13, 1.8}},
+}
+
+
+func gnfsParametersFor(n *big.Int) gnfsParameters {
+
+	digits := len(n.String())
+
+	for _, row := range gnfsParameterTable {
+		if digits <= row.digits {
+			return row.params
+		}
+	}
+
+	return gnfsParameterTable[len(gnfsParameterTable)-1].params
+}
+
+
+func (this gnfsParameters) String() string {
+	return fmt.Sprint("degree ", this.degree, " rational-bound ", this.rationalBound, " algebraic-bound ", this.algebraicBound,
+			" half-width ", this.halfWidth, " max-b ", this.maxB, " fudge ", this.thresholdFudge)
+}
+
+
+/* (a, b) with the exponents of -1 and the rational primes, of the algebraic primes and the characters, in this
+order. the exponents are needed again for the square roots, the characters only as bits */
+type gnfsRelation struct {
+	a, b int64
+	exponents []int
+}
+
+
+/* p and a root r of f mod p, the prime ideal (p, alpha - r) */
+type algebraicPrime struct {
+	p, r int64
+}
+
+
+/* returns nil, nil if n cannot be factorized, an error if it is out of reach of this implementation */
+func gnfs(n *big.Int, opts options) (*big.Int, *big.Int, error) {
+
+	t1 := time.Now()
+
+	if digits, maxDigits := len(n.String()), gnfsParameterTable[len(gnfsParameterTable)-1].digits; digits > maxDigits {
+		return nil, nil, fmt.Errorf("%d digits are beyond the %d the number field sieve is tuned for", digits, maxDigits)
+	}
+
+	params := gnfsParametersFor(n)
+
+	f, m := baseMPolynomial(n, params.degree)
+
+	/* f(m) = n, any factor of f would give one of n. an inert prime also shows that there is none */
+	if inertPrime(f, gnfsInertPrimeStart) == 0 {
+		return nil, nil, fmt.Errorf("no prime found that %v is irreducible mod", f)
+	}
+
+	/* x = f'(m) sqrt(prod (a - b m)) has to be invertible mod n */
+	g := big.NewInt(0).GCD(nil, nil, polynomialValueMod(polynomialDerivative(f), m, n), n)
+	if g.Cmp(misc.One) != 0 {
+		x, y := factorsOfN(n, g, misc.One)
+		return x, y, nil
+	}
+
+	bases := newGNFSFactorBases(f, m, params)
+
+	t2 := time.Now()
+
+	wanted := bases.columns() + gnfsExtraRelations
+	relations := bases.sieve(params, wanted)
+
+	t3 := time.Now()
+
+	var x, y *big.Int
+
+	if len(relations) >= wanted {
+
+		exponents := make([][]int, len(relations))
+		for i, relation := range relations {
+			exponents[i] = relation.exponents
+		}
+
+		for _, dependency := range findDependencies(exponents, opts.solver, opts.filter) {
+
+			X, Y := bases.squares(n, relations, dependency)
+			if X == nil {
+				continue
+			}
+
+			difference := big.NewInt(0).Sub(X, Y)
+			sum := big.NewInt(0).Add(X, Y)
+
+			if x, y = factorsOfN(n, difference.Mod(difference, n), sum.Mod(sum, n)); x != nil {
+				break
+			}
+		}
+	}
+
+	t4 := time.Now()
+
+	if x != nil && y != nil && x.Cmp(y) == 1 {
+		x, y = y, x
+	}
+
+	/* one line per split with the effective parameters, as for factorize() */
+	if x != nil && y != nil {
+		fmt.Print(n, " + ", x, y)
+	} else {
+		fmt.Print(n, " - - -")
+	}
+	if opts.benchmark == true {
+		fmt.Print(" wall ", nanoSecondsToString(t4.Sub(t1).Nanoseconds()),
+		" sieve ", nanoSecondsToString(t3.Sub(t2).Nanoseconds()),
+		" combing ", nanoSecondsToString(t4.Sub(t3).Nanoseconds()),
+		" relations ", len(relations))
+	}
+	fmt.Print(" polynomial ", f, " m ", m, " ", params)
+	fmt.Println()
+
+	return x, y, nil
+}
+
+
+/* the monic f of the given degree with f(m) = n for m = floor(n^(1/degree)), the coefficients are the digits of n
+in base m, moved into [-m/2, m/2] */
+func baseMPolynomial(n *big.Int, degree int) ([]*big.Int, *big.Int) {
+
+	m := misc.RootFloor(n, degree)
+
+	f := make([]*big.Int, degree + 1)
+	rest := big.NewInt(0).Set(n)
+
+	for i := 0; i < degree; i += 1 {
+		f[i] = big.NewInt(0)
+		rest.QuoRem(rest, m, f[i])
+	}
+	f[degree] = rest
+
+	halfM := big.NewInt(0).Rsh(m, 1)
+
+	for i := 0; i < degree; i += 1 {
+		if f[i].Cmp(halfM) > 0 {
+			f[i].Sub(f[i], m)
+			f[i+1].Add(f[i+1], misc.One)
+		}
+	}
+
+	/* m^d <= n < (m + 1)^d, so the leading digit is 1 and the one below it is below d */
+	if f[degree].Cmp(misc.One) != 0 {
+		panic("baseMPolynomial(): polynomial is not monic")
+	}
+
+	return f, m
+}
+
+
+/* *** gnfsFactorBases *** ************************************************* */
+
+type gnfsFactorBases struct {
+	f []*big.Int
+	m *big.Int
+
+	rational []int64
+	mModP []int64 /* m mod each rational prime */
+	algebraic []algebraicPrime
+	characters []algebraicPrime /* (q, s) with f'(s) != 0 (mod q), beyond the algebraic primes */
+}
+
+
+func newGNFSFactorBases(f []*big.Int, m *big.Int, params gnfsParameters) *gnfsFactorBases {
+
+	ret := &gnfsFactorBases{f: f, m: m}
+
+	P := big.NewInt(0)
+	t := big.NewInt(0)
+
+	ret.rational = misc.Primes(params.rationalBound)
+	ret.mModP = make([]int64, len(ret.rational))
+	for i, p := range ret.rational {
+		ret.mModP[i] = t.Mod(m, P.SetInt64(p)).Int64()
+	}
+
+	for _, p := range misc.Primes(params.algebraicBound) {
+		for _, r := range polynomialRootsSmallInt(polynomialModPrimeSmallInt(f, p), p) {
+			ret.algebraic = append(ret.algebraic, algebraicPrime{p, r})
+		}
+	}
+
+	fPrime := polynomialDerivative(f)
+
+	for q := params.algebraicBound + 1; len(ret.characters) < gnfsCharacters; q += 1 {
+
+		if P.SetInt64(q).ProbablyPrime(20) == false {
+			continue
+		}
+
+		for _, s := range polynomialRootsSmallInt(polynomialModPrimeSmallInt(f, q), q) {
+			if polynomialValueMod(fPrime, t.SetInt64(s), P).Sign() != 0 && len(ret.characters) < gnfsCharacters {
+				ret.characters = append(ret.characters, algebraicPrime{q, s})
+			}
+		}
+	}
+
+	return ret
+}
+
+
+/* the sign, the rational primes, the algebraic primes and the characters */
+func (this gnfsFactorBases) columns() int {
+	return 1 + len(this.rational) + len(this.algebraic) + len(this.characters)
+}
+
+
+/* sieves b = 1, 2, ... until there are wanted relations or maxB is reached */
+func (this gnfsFactorBases) sieve(params gnfsParameters, wanted int) []gnfsRelation {
+
+	A := params.halfWidth
+	width := 2*A
+
+	d := len(this.f) - 1
+
+	coefficients := make([]float64, d + 1)
+	for i, c := range this.f {
+		coefficients[i], _ = big.NewFloat(0).SetInt(c).Float64()
+	}
+	mFloat, _ := big.NewFloat(0).SetInt(this.m).Float64()
+
+	/* log2|b^d f(a/b)| */
+	log2AlgebraicNorm := func(a, b float64) float64 {
+		norm := 0.0
+		for i := d; i >= 0; i -= 1 {
+			norm = norm * a + coefficients[i] * math.Pow(b, float64(d - i))
+		}
+		return math.Log2(math.Max(1.0, math.Abs(norm)))
+	}
+
+	/* scale the logarithms down if the largest norm would overflow a byte */
+	maxLog := math.Max(math.Log2(float64(A) + float64(params.maxB) * mFloat),
+			math.Max(log2AlgebraicNorm(float64(A), float64(params.maxB)), log2AlgebraicNorm(0, float64(params.maxB))))
+	logScale := 1.0
+	if maxLog > 240 {
+		logScale = 240 / maxLog
+	}
+
+	logOf := func(p int64) uint8 {
+		return uint8(math.Max(1.0, math.Floor(math.Log2(float64(p)) * logScale + 0.5)))
+	}
+
+	rationalLogs := make([]uint8, len(this.rational))
+	for i, p := range this.rational {
+		rationalLogs[i] = logOf(p)
+	}
+
+	algebraicLogs := make([]uint8, len(this.algebraic))
+	for i, ideal := range this.algebraic {
+		algebraicLogs[i] = logOf(ideal.p)
+	}
+
+	rationalFudge := math.Log2(float64(this.rational[len(this.rational)-1])) * params.thresholdFudge
+	algebraicFudge := math.Log2(float64(this.algebraic[len(this.algebraic)-1].p)) * params.thresholdFudge
+
+	rationalSieve := make([]uint8, width)
+	algebraicSieve := make([]uint8, width)
+
+	ret := []gnfsRelation{}
+
+	for b := int64(1); b <= params.maxB && len(ret) < wanted; b += 1 {
+
+		for i := range rationalSieve {
+			rationalSieve[i] = 0
+			algebraicSieve[i] = 0
+		}
+
+		/* position j is a = j - A. p | a - b m iff a = b m, p | N(a - b alpha) iff a = b r (mod p) */
+		for i, p := range this.rational {
+			logP := rationalLogs[i]
+			for j := (misc.MulModSmallInt(b, this.mModP[i], p) + A) % p; j < width; j += p {
+				rationalSieve[j] += logP
+			}
+		}
+
+		for i, ideal := range this.algebraic {
+			p := ideal.p
+			logP := algebraicLogs[i]
+			for j := (misc.MulModSmallInt(b, ideal.r, p) + A) % p; j < width; j += p {
+				algebraicSieve[j] += logP
+			}
+		}
+
+		/* |a| is small against b m */
+		rationalThreshold := (math.Log2(float64(b) * mFloat) - rationalFudge) * logScale
+
+		for j := int64(0); j < width && len(ret) < wanted; j += 1 {
+
+			if float64(rationalSieve[j]) < rationalThreshold {
+				continue
+			}
+
+			a := j - A
+
+			if float64(algebraicSieve[j]) < (log2AlgebraicNorm(float64(a), float64(b)) - algebraicFudge) * logScale {
+				continue
+			}
+
+			if gcdSmallInt(uint64(abs64(a)), uint64(b)) != 1 {
+				continue
+			}
+
+			if exponents := this.trialDivide(a, b); exponents != nil {
+				ret = append(ret, gnfsRelation{a, b, exponents})
+			}
+		}
+	}
+
+	return ret
+}
+
+
+/* the exponents of a - b m and a - b alpha if both are smooth, nil otherwise. a and b must be coprime */
+func (this gnfsFactorBases) trialDivide(a, b int64) []int {
+
+	exponents := make([]int, this.columns())
+
+	P := big.NewInt(0)
+	quotient := big.NewInt(0)
+	rest := big.NewInt(0)
+
+	divide := func(v *big.Int, p int64) int {
+		k := 0
+		P.SetInt64(p)
+		for {
+			quotient.QuoRem(v, P, rest)
+			if rest.Sign() != 0 {
+				return k
+			}
+			v.Set(quotient)
+			k += 1
+		}
+	}
+
+	/* rational side */
+	v := big.NewInt(b)
+	v.Mul(v, this.m)
+	v.Sub(big.NewInt(a), v)
+
+	if v.Sign() < 0 {
+		exponents[0] = 1
+		v.Neg(v)
+	}
+
+	for i, p := range this.rational {
+		if mod64(a - misc.MulModSmallInt(b, this.mModP[i], p), p) == 0 {
+			exponents[1+i] = divide(v, p)
+		}
+	}
+
+	if v.Cmp(misc.One) != 0 {
+		return nil
+	}
+
+	/* algebraic side, N(a - b alpha) = b^d f(a/b) */
+	v.SetInt64(0)
+	A := big.NewInt(a)
+	B := big.NewInt(b)
+	power := big.NewInt(1)
+	t := big.NewInt(0)
+
+	for i := len(this.f) - 1; i >= 0; i -= 1 {
+		v.Mul(v, A)
+		v.Add(v, t.Mul(this.f[i], power))
+		power.Mul(power, B)
+	}
+	v.Abs(v)
+
+	offset := 1 + len(this.rational)
+
+	for i, ideal := range this.algebraic {
+		if mod64(a - misc.MulModSmallInt(b, ideal.r, ideal.p), ideal.p) == 0 {
+			exponents[offset+i] = divide(v, ideal.p)
+		}
+	}
+
+	if v.Cmp(misc.One) != 0 {
+		return nil
+	}
+
+	/* a bit for every character (a - b s | q) = -1 */
+	offset += len(this.algebraic)
+
+	for i, character := range this.characters {
+		if misc.LegendreSmallInt(mod64(a - misc.MulModSmallInt(b, character.r, character.p), character.p), character.p) == -1 {
+			exponents[offset+i] = 1
+		}
+	}
+
+	return exponents
+}
+
+
+/* x = f'(m) sqrt(prod (a - b m)) and y, the image of the algebraic square root, with x^2 = y^2 (mod n) for the
+relations of a dependency. nil, nil if the algebraic side turns out to be no square */
+func (this gnfsFactorBases) squares(n *big.Int, relations []gnfsRelation, dependency []int) (*big.Int, *big.Int) {
+
+	sum := make([]int, 1 + len(this.rational) + len(this.algebraic))
+	pairs := make([][2]int64, 0, len(dependency))
+
+	for _, i := range dependency {
+		for j := range sum {
+			sum[j] += relations[i].exponents[j]
+		}
+		pairs = append(pairs, [2]int64{relations[i].a, relations[i].b})
+	}
+
+	x := polynomialValueMod(polynomialDerivative(this.f), this.m, n)
+
+	P := big.NewInt(0)
+	E := big.NewInt(0)
+
+	for j, exponent := range sum {
+		if exponent % 2 != 0 {
+			panic("gnfsFactorBases.squares(): odd exponent in a dependency")
+		}
+		if j > 0 && j <= len(this.rational) && exponent > 0 {
+			P.Exp(P.SetInt64(this.rational[j-1]), E.SetInt64(int64(exponent / 2)), n)
+			x.Mul(x, P)
+			x.Mod(x, n)
+		}
+	}
+
+	/* sqrt(prod |N(a - b alpha)|) from the exponents of the algebraic primes, each of norm p */
+	sqrtNorm := big.NewInt(1)
+	offset := 1 + len(this.rational)
+
+	for i, ideal := range this.algebraic {
+		if exponent := sum[offset+i]; exponent > 0 {
+			sqrtNorm.Mul(sqrtNorm, P.Exp(P.SetInt64(ideal.p), E.SetInt64(int64(exponent / 2)), nil))
+		}
+	}
+
+	y, ok := algebraicSquareRoot(this.f, this.m, n, pairs, sqrtNorm)
+	if ok == false {
+		return nil, nil
+	}
+
+	return x, y
+}
+
+
+func abs64(a int64) int64 {
+	if a < 0 {
+		return -a
+	}
+	return a
+}
+
+
+/* a mod p in [0, p) */
+func mod64(a, p int64) int64 {
+
+	a %= p
+	if a < 0 {
+		a += p
+	}
+
+	return a
+}
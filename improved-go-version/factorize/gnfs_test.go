@@ -0,0 +1,249 @@
+package main
+
+
+import (
+	"math/big"
+	"math/rand"
+	"testing"
+
+	"github.com/hydroo/quadratic-sieve/misc"
+)
+
+
+func TestBaseMPolynomial(t *testing.T) {
+
+	nums := []string{
+			"2100425389650817",
+			"21000042556009655503",
+			"210000000004311400000010560993",
+			"1000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000007",
+			}
+
+	for _, s := range nums {
+
+		n, _ := big.NewInt(0).SetString(s, 10)
+
+		for _, degree := range []int{3, 5} {
+
+			f, m := baseMPolynomial(n, degree)
+
+			if polynomialValue(f, m).Cmp(n) != 0 {
+				t.Error("f(m) != n for f =", f, "m =", m, "n =", n)
+			}
+
+			halfM := big.NewInt(0).Rsh(m, 1)
+			for i := 0; i < degree; i += 1 {
+				if big.NewInt(0).Abs(f[i]).Cmp(halfM) > 0 {
+					t.Error("coefficient", i, "of", f, "is beyond m/2 =", halfM)
+				}
+			}
+		}
+	}
+}
+
+
+func TestPolynomialRootsSmallInt(t *testing.T) {
+
+	/* (x - 1)(x - 5)(x - 100) and an irreducible cubic mod every p */
+	f := []*big.Int{big.NewInt(-500), big.NewInt(605), big.NewInt(-106), big.NewInt(1)}
+
+	for _, p := range []int64{3, 7, 101, 1009, 65537, 1000003} {
+
+		roots := polynomialRootsSmallInt(polynomialModPrimeSmallInt(f, p), p)
+
+		/* every residue tried */
+		expected := []int64{}
+		for r := int64(0); r < p && r < 1000; r += 1 {
+			if polynomialValueMod(f, big.NewInt(r), big.NewInt(p)).Sign() == 0 {
+				expected = append(expected, r)
+			}
+		}
+
+		if len(roots) != len(expected) {
+			t.Error("roots of", f, "mod", p, "are", expected, "not", roots)
+			continue
+		}
+
+		for i := range roots {
+			if roots[i] != expected[i] {
+				t.Error("roots of", f, "mod", p, "are", expected, "not", roots)
+			}
+		}
+	}
+
+	/* x^3 - 2 is irreducible mod p = 1 (mod 3) with 2 a non-cube, reducible mod p = 2 (mod 3) */
+	g := []int64{-2 + 13, 0, 0, 1}
+	if irreducibleModPrimeSmallInt(g, 13) == false {
+		t.Error("x^3 - 2 is irreducible mod 13")
+	}
+
+	g = []int64{-2 + 29, 0, 0, 1}
+	if irreducibleModPrimeSmallInt(g, 29) == true {
+		t.Error("x^3 - 2 is reducible mod 29")
+	}
+}
+
+
+func TestAlgebraicSquareRoot(t *testing.T) {
+
+	n, _ := big.NewInt(0).SetString("210000000004311400000010560993", 10)
+
+	rng := rand.New(rand.NewSource(1)) /* fixed seed, results are reproducible */
+
+	for _, degree := range []int{3, 5} {
+
+		f, m := baseMPolynomial(n, degree)
+
+		/* every pair twice, gamma = +-f'(alpha) prod (a - b alpha) over the distinct ones */
+		pairs := [][2]int64{}
+		expected := polynomialValueMod(polynomialDerivative(f), m, n)
+		sqrtNorm := big.NewInt(1)
+
+		for len(pairs) < 2 * 300 {
+
+			a, b := rng.Int63n(1 << 16) - (1 << 15), 1 + rng.Int63n(1 << 12)
+			if gcdSmallInt(uint64(abs64(a)), uint64(b)) != 1 {
+				continue
+			}
+
+			pairs = append(pairs, [2]int64{a, b}, [2]int64{a, b})
+
+			value := big.NewInt(b)
+			value.Mul(value, m)
+			value.Sub(big.NewInt(a), value)
+			expected.Mul(expected, value)
+			expected.Mod(expected, n)
+
+			/* |b^d f(a/b)| */
+			norm := big.NewInt(0)
+			for i := range f {
+				term := big.NewInt(0).Exp(big.NewInt(a), big.NewInt(int64(i)), nil)
+				term.Mul(term, big.NewInt(0).Exp(big.NewInt(b), big.NewInt(int64(degree - i)), nil))
+				norm.Add(norm, term.Mul(term, f[i]))
+			}
+			sqrtNorm.Mul(sqrtNorm, norm.Abs(norm))
+		}
+
+		y, ok := algebraicSquareRoot(f, m, n, pairs, sqrtNorm)
+		if ok == false {
+			t.Error("no square root of the square of", len(pairs) / 2, "pairs for", f)
+			continue
+		}
+
+		negative := big.NewInt(0).Sub(n, expected)
+		if y.Cmp(expected) != 0 && y.Cmp(negative) != 0 {
+			t.Error("the square root of the square of", len(pairs) / 2, "pairs for", f, "is", y, "not +-", expected)
+		}
+
+		/* one pair once, no square */
+		if y, ok := algebraicSquareRoot(f, m, n, pairs[1:], sqrtNorm); ok == true {
+			t.Error("square root", y, "of a non-square for", f)
+		}
+	}
+}
+
+
+func TestGNFS(t *testing.T) {
+
+	n, _ := big.NewInt(0).SetString("21000042556009655503", 10)
+
+	params := gnfsParametersFor(n)
+	f, m := baseMPolynomial(n, params.degree)
+	bases := newGNFSFactorBases(f, m, params)
+
+	wanted := bases.columns() + gnfsExtraRelations
+	relations := bases.sieve(params, wanted)
+
+	if len(relations) < wanted {
+		t.Error("the sieve found", len(relations), "of", wanted, "relations")
+	}
+
+	/* a - b m and the norm of a - b alpha from the exponents */
+	offset := 1 + len(bases.rational)
+
+	for _, relation := range relations {
+
+		rational := big.NewInt(1)
+		if relation.exponents[0] == 1 {
+			rational.SetInt64(-1)
+		}
+		for i, p := range bases.rational {
+			rational.Mul(rational, big.NewInt(0).Exp(big.NewInt(p), big.NewInt(int64(relation.exponents[1+i])), nil))
+		}
+
+		expected := big.NewInt(relation.b)
+		expected.Mul(expected, m)
+		expected.Sub(big.NewInt(relation.a), expected)
+
+		if rational.Cmp(expected) != 0 {
+			t.Error("(", relation.a, ",", relation.b, "): a - b m =", expected, "not", rational)
+		}
+
+		algebraic := big.NewInt(1)
+		for i, ideal := range bases.algebraic {
+			algebraic.Mul(algebraic, big.NewInt(0).Exp(big.NewInt(ideal.p), big.NewInt(int64(relation.exponents[offset+i])), nil))
+		}
+
+		/* b^d f(a/b) */
+		norm := big.NewInt(0)
+		for i := range f {
+			term := big.NewInt(0).Exp(big.NewInt(relation.a), big.NewInt(int64(i)), nil)
+			term.Mul(term, big.NewInt(0).Exp(big.NewInt(relation.b), big.NewInt(int64(len(f) - 1 - i)), nil))
+			norm.Add(norm, term.Mul(term, f[i]))
+		}
+
+		if algebraic.Cmp(norm.Abs(norm)) != 0 {
+			t.Error("(", relation.a, ",", relation.b, "): |N(a - b alpha)| =", norm, "not", algebraic)
+		}
+	}
+
+	nums := []string{
+			"2100425389650817",
+			}
+
+	xTimesY := big.NewInt(0)
+
+	/* the same back end as for the sieves */
+	for _, solver := range []linearSolver{solverGauss, solverLanczos, solverWiedemann} {
+		for _, filter := range []bool{false, true} {
+			for _, s := range nums {
+
+				n, _ := big.NewInt(0).SetString(s, 10)
+
+				x, y, err := gnfs(n, options{solver: solver, filter: filter})
+
+				if err != nil {
+					t.Error(n, err)
+					continue
+				} else if x == nil || y == nil {
+					t.Error(n, "could not be factorized, solver", solver, "filter", filter)
+					continue
+				}
+
+				if xTimesY.Mul(x, y).Cmp(n) != 0 || x.Cmp(misc.One) == 0 || y.Cmp(misc.One) == 0 {
+					t.Error(n, "!=", x, "*", y)
+				}
+			}
+		}
+	}
+
+	/* through the factor command's path */
+	n, _ = big.NewInt(0).SetString("2100000425560009718663", 10)
+	n.Mul(n, big.NewInt(1009))
+	opts := defaultOptions()
+	opts.method = methodGNFS
+	opts.fermatIterations = 0
+
+	factors, err := factorCompletely(n, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testFactorization(t, n, factors)
+
+	/* beyond gnfsParameterTable */
+	n, _ = big.NewInt(0).SetString("6734319982431950692508617574486143524749", 10)
+	if x, y, err := gnfs(n, defaultOptions()); err == nil {
+		t.Error("gnfs on", n, "gave", x, y, "instead of an error")
+	}
+}
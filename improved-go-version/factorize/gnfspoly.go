@@ -0,0 +1,296 @@
+package main
+
+/* polynomials for the number field sieve.
+ *
+ * over the integers as []*big.Int and mod a prime p < 2^31 as []int64, the coefficient of x^i at index i. the
+ * ones mod p have no leading zeros, the zero polynomial is empty */
+
+import (
+	"math/big"
+	"sort"
+
+	"github.com/hydroo/quadratic-sieve/misc"
+)
+
+
+/* below this the roots mod p are found by trying every residue */
+const rootSearchMax = 64
+
+
+/* *** over the integers *** *********************************************** */
+
+/* f(x) */
+func polynomialValue(f []*big.Int, x *big.Int) *big.Int {
+
+	ret := big.NewInt(0)
+
+	for i := len(f) - 1; i >= 0; i -= 1 {
+		ret.Mul(ret, x)
+		ret.Add(ret, f[i])
+	}
+
+	return ret
+}
+
+
+/* f(x) mod n in [0, n) */
+func polynomialValueMod(f []*big.Int, x, n *big.Int) *big.Int {
+
+	ret := big.NewInt(0)
+
+	for i := len(f) - 1; i >= 0; i -= 1 {
+		ret.Mul(ret, x)
+		ret.Add(ret, f[i])
+		ret.Mod(ret, n)
+	}
+
+	return ret
+}
+
+
+/* f' */
+func polynomialDerivative(f []*big.Int) []*big.Int {
+
+	ret := make([]*big.Int, len(f) - 1)
+
+	for i := range ret {
+		ret[i] = big.NewInt(int64(i + 1))
+		ret[i].Mul(ret[i], f[i+1])
+	}
+
+	return ret
+}
+
+
+/* *** mod p *** *********************************************************** */
+
+/* f mod p */
+func polynomialModPrimeSmallInt(f []*big.Int, p int64) []int64 {
+
+	ret := make([]int64, len(f))
+	P := big.NewInt(p)
+	c := big.NewInt(0)
+
+	for i := range f {
+		ret[i] = c.Mod(f[i], P).Int64()
+	}
+
+	return trimPolynomialSmallInt(ret)
+}
+
+
+/* a without leading zeros */
+func trimPolynomialSmallInt(a []int64) []int64 {
+
+	for len(a) > 0 && a[len(a)-1] == 0 {
+		a = a[:len(a)-1]
+	}
+
+	return a
+}
+
+
+/* a - b mod p */
+func polynomialSubSmallInt(a, b []int64, p int64) []int64 {
+
+	ret := make([]int64, len(a))
+	copy(ret, a)
+
+	for len(ret) < len(b) {
+		ret = append(ret, 0)
+	}
+
+	for i := range b {
+		ret[i] -= b[i]
+		if ret[i] < 0 {
+			ret[i] += p
+		}
+	}
+
+	return trimPolynomialSmallInt(ret)
+}
+
+
+/* quotient and remainder of a / b mod p. b must not be zero */
+func polynomialDivModSmallInt(a, b []int64, p int64) ([]int64, []int64) {
+
+	if len(a) < len(b) {
+		return []int64{}, trimPolynomialSmallInt(append([]int64{}, a...))
+	}
+
+	r := make([]int64, len(a))
+	copy(r, a)
+
+	q := make([]int64, len(a) - len(b) + 1)
+	inverse := misc.ModInverseSmallInt(b[len(b)-1], p)
+
+	for i := len(q) - 1; i >= 0; i -= 1 {
+
+		c := misc.MulModSmallInt(r[i+len(b)-1], inverse, p)
+		q[i] = c
+
+		if c == 0 {
+			continue
+		}
+
+		for j := range b {
+			r[i+j] -= misc.MulModSmallInt(c, b[j], p)
+			if r[i+j] < 0 {
+				r[i+j] += p
+			}
+		}
+	}
+
+	return trimPolynomialSmallInt(q), trimPolynomialSmallInt(r[:len(b)-1])
+}
+
+
+/* a b mod g and p */
+func polynomialMulModSmallInt(a, b, g []int64, p int64) []int64 {
+
+	if len(a) == 0 || len(b) == 0 {
+		return []int64{}
+	}
+
+	product := make([]int64, len(a) + len(b) - 1)
+
+	for i := range a {
+		for j := range b {
+			product[i+j] = (product[i+j] + misc.MulModSmallInt(a[i], b[j], p)) % p
+		}
+	}
+
+	_, ret := polynomialDivModSmallInt(product, g, p)
+
+	return ret
+}
+
+
+/* a^e mod g and p, e >= 0 */
+func polynomialPowModSmallInt(a []int64, e int64, g []int64, p int64) []int64 {
+
+	_, ret := polynomialDivModSmallInt([]int64{1}, g, p)
+	_, base := polynomialDivModSmallInt(a, g, p)
+
+	for ; e > 0; e >>= 1 {
+		if e & 1 == 1 {
+			ret = polynomialMulModSmallInt(ret, base, g, p)
+		}
+		base = polynomialMulModSmallInt(base, base, g, p)
+	}
+
+	return ret
+}
+
+
+/* a^e mod g and p for an e >= 0 beyond 64 bits */
+func polynomialBigPowModSmallInt(a []int64, e *big.Int, g []int64, p int64) []int64 {
+
+	_, ret := polynomialDivModSmallInt([]int64{1}, g, p)
+	_, base := polynomialDivModSmallInt(a, g, p)
+
+	for i := e.BitLen() - 1; i >= 0; i -= 1 {
+		ret = polynomialMulModSmallInt(ret, ret, g, p)
+		if e.Bit(i) == 1 {
+			ret = polynomialMulModSmallInt(ret, base, g, p)
+		}
+	}
+
+	return ret
+}
+
+
+/* the monic gcd of a and b mod p */
+func polynomialGCDSmallInt(a, b []int64, p int64) []int64 {
+
+	a = trimPolynomialSmallInt(append([]int64{}, a...))
+	b = trimPolynomialSmallInt(append([]int64{}, b...))
+
+	for len(b) > 0 {
+		_, r := polynomialDivModSmallInt(a, b, p)
+		a, b = b, r
+	}
+
+	if len(a) > 0 {
+		inverse := misc.ModInverseSmallInt(a[len(a)-1], p)
+		for i := range a {
+			a[i] = misc.MulModSmallInt(a[i], inverse, p)
+		}
+	}
+
+	return a
+}
+
+
+/* the distinct roots of f mod p in ascending order. f must not be zero mod p */
+func polynomialRootsSmallInt(f []int64, p int64) []int64 {
+
+	ret := []int64{}
+
+	if p < rootSearchMax {
+		for r := int64(0); r < p; r += 1 {
+			value := int64(0)
+			for i := len(f) - 1; i >= 0; i -= 1 {
+				value = (value * r + f[i]) % p
+			}
+			if value == 0 {
+				ret = append(ret, r)
+			}
+		}
+		return ret
+	}
+
+	/* the product of the linear factors of f is gcd(f, x^p - x) */
+	x := []int64{0, 1}
+	g := polynomialGCDSmallInt(f, polynomialSubSmallInt(polynomialPowModSmallInt(x, p, f, p), x, p), p)
+
+	ret = splitLinearFactorsSmallInt(g, p, 1)
+	sort.Slice(ret, func(i, j int) bool { return ret[i] < ret[j] })
+
+	return ret
+}
+
+
+/* the roots of the monic g, a product of distinct linear factors mod an odd p, with cantor and zassenhaus:
+gcd(g, (x + a)^((p-1)/2) - 1) keeps the roots r with r + a a square. a is tried upwards from the one given */
+func splitLinearFactorsSmallInt(g []int64, p, a int64) []int64 {
+
+	if len(g) <= 1 {
+		return []int64{}
+	} else if len(g) == 2 {
+		return []int64{(p - g[0]) % p}
+	}
+
+	for ; ; a += 1 {
+
+		h := polynomialPowModSmallInt([]int64{a % p, 1}, (p - 1) / 2, g, p)
+		h = polynomialSubSmallInt(h, []int64{1}, p)
+
+		d := polynomialGCDSmallInt(g, h, p)
+
+		if len(d) > 1 && len(d) < len(g) {
+			q, _ := polynomialDivModSmallInt(g, d, p)
+			return append(splitLinearFactorsSmallInt(d, p, a + 1), splitLinearFactorsSmallInt(q, p, a + 1)...)
+		}
+	}
+}
+
+
+/* whether f of prime degree is irreducible mod p, after rabin: x^(p^d) = x (mod f) and no linear factor */
+func irreducibleModPrimeSmallInt(f []int64, p int64) bool {
+
+	d := len(f) - 1
+	x := []int64{0, 1}
+
+	h := x
+	for i := 0; i < d; i += 1 {
+
+		h = polynomialPowModSmallInt(h, p, f, p)
+
+		if i == 0 && len(polynomialGCDSmallInt(f, polynomialSubSmallInt(h, x, p), p)) > 1 {
+			return false
+		}
+	}
+
+	return len(polynomialSubSmallInt(h, x, p)) == 0
+}
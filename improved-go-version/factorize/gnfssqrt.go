@@ -0,0 +1,277 @@
+package main
+
+/* the algebraic square root of the number field sieve, after couveignes.
+ *
+ * for a dependency S, P = f'(alpha)^2 prod (a - b alpha) over S is the square of some gamma in Z[alpha], since f
+ * is monic. P has as many digits as all of the relations of S together and is never computed. gamma is found mod
+ * many primes p that f stays irreducible mod instead, where Z[alpha] / p is the field GF(p^d): with p = 3 (mod 4)
+ * and d odd, q = p^d = 3 (mod 4) and P^((q + 1) / 4) is a square root of P mod p.
+ *
+ * that root is only known up to its sign. d is odd, so N(-gamma) = -N(gamma), and the sign mod every p is the
+ * one whose norm x^((q - 1) / (p - 1)) matches N(gamma) = N(f'(alpha)) sqrt(prod |N(a - b alpha)|), known from the
+ * exponents of the algebraic primes. with the roots gamma_i mod p_i and M the product of the p_i, every
+ * coefficient of gamma is sum x_i M / p_i - r M for x_i = gamma_i (M / p_i)^(-1) mod p_i and r the integer
+ * nearest to sum x_i / p_i, as long as M is well beyond twice the coefficient. that goes to gamma(m) mod n
+ * directly, all numbers stay below n.
+ *
+ * the coefficients of gamma are only guessed at beforehand. the primes are doubled until gamma(m)^2 = P(m)
+ * (mod n) */
+
+import (
+	"math"
+	"math/big"
+
+	"github.com/hydroo/quadratic-sieve/misc"
+)
+
+
+/* primes tried by inertPrime() before giving up */
+const inertPrimeTries = 1000
+
+/* bits of M beyond the guess at twice the coefficients of gamma */
+const couveignesMargin = 64
+
+/* times the bits of M are doubled before P is taken for no square */
+const couveignesDoublings = 3
+
+
+/* the first prime p = 3 (mod 4) from start on that the monic f of odd prime degree is irreducible mod, 0 if none
+of the next inertPrimeTries is. there is one if f is irreducible over the integers, unless its galois group is
+unusual */
+func inertPrime(f []*big.Int, start int64) int64 {
+
+	P := big.NewInt(0)
+
+	for p, tries := start | 3, 0; tries < inertPrimeTries && p <= maxFactorBasePrime; p += 4 {
+
+		if P.SetInt64(p).ProbablyPrime(20) == false {
+			continue
+		}
+
+		if irreducibleModPrimeSmallInt(polynomialModPrimeSmallInt(f, p), p) == true {
+			return p
+		}
+
+		tries += 1
+	}
+
+	return 0
+}
+
+
+/* gamma(m) mod n for the gamma with gamma^2 = f'(alpha)^2 prod (a - b alpha) over the pairs and the norm
+N(f'(alpha)) sqrtNorm, sqrtNorm the square root of prod |N(a - b alpha)|. false if there is no such gamma. f must
+be monic of odd degree and irreducible */
+func algebraicSquareRoot(f []*big.Int, m, n *big.Int, pairs [][2]int64, sqrtNorm *big.Int) (*big.Int, bool) {
+
+	/* P(m) mod n for the check */
+	square := polynomialValueMod(polynomialDerivative(f), m, n)
+	square.Mul(square, square)
+
+	t := big.NewInt(0)
+	for _, ab := range pairs {
+		t.SetInt64(ab[1])
+		t.Mul(t, m)
+		t.Sub(big.NewInt(ab[0]), t)
+		square.Mul(square, t)
+		square.Mod(square, n)
+	}
+
+	bits := couveignesBits(f, pairs)
+
+	primes := []int64{}
+	roots := [][]int64{}
+	log2M := 0.0
+
+	P := big.NewInt(0)
+	p := int64(gnfsInertPrimeStart)
+
+	for doubling := 0; doubling <= couveignesDoublings; doubling += 1 {
+
+		for log2M < bits {
+
+			if p = inertPrime(f, p + 1); p == 0 {
+				return nil, false
+			}
+
+			/* p divides the norm of gamma, the sign cannot be told */
+			norm := t.Mod(sqrtNorm, P.SetInt64(p)).Int64()
+			if norm == 0 {
+				continue
+			}
+
+			root, ok := squareRootModInertPrime(f, pairs, norm, p)
+			if ok == false {
+				return nil, false
+			}
+
+			primes = append(primes, p)
+			roots = append(roots, root)
+			log2M += math.Log2(float64(p))
+		}
+
+		y := couveignesImage(primes, roots, m, n)
+
+		if t.Mul(y, y).Mod(t, n).Cmp(square) == 0 {
+			return y, true
+		}
+
+		bits *= 2
+	}
+
+	return nil, false
+}
+
+
+/* a guess at the bits of M: the conjugates of gamma are below |f'(R)| prod sqrt(|a| + |b| R) for the bound R on
+the roots of f, the coefficients below that times about R^d */
+func couveignesBits(f []*big.Int, pairs [][2]int64) float64 {
+
+	d := len(f) - 1
+
+	/* R = 1 + max |f_i| */
+	log2R := 0.0
+	for _, c := range f {
+		log2R = math.Max(log2R, float64(c.BitLen()) + 1)
+	}
+
+	ret := math.Log2(float64(d)) + float64(d - 1) * log2R + log2R
+
+	for _, ab := range pairs {
+		ret += 0.5 * math.Log2(float64(abs64(ab[0])) + float64(abs64(ab[1])) * math.Exp2(log2R))
+	}
+
+	return ret + float64(d) * log2R + 1 + couveignesMargin
+}
+
+
+/* gamma mod the inert prime p with d coefficients, the square root of P in GF(p^d) whose norm is N(f'(alpha)) sqrtNorm
+mod p. false if P is no square mod p */
+func squareRootModInertPrime(f []*big.Int, pairs [][2]int64, sqrtNorm, p int64) ([]int64, bool) {
+
+	d := len(f) - 1
+
+	g := polynomialModPrimeSmallInt(f, p)
+	fPrime := polynomialModPrimeSmallInt(polynomialDerivative(f), p)
+
+	P := polynomialMulModSmallInt(fPrime, fPrime, g, p)
+	for _, ab := range pairs {
+		P = polynomialMulModSmallInt(P, trimPolynomialSmallInt([]int64{mod64(ab[0], p), mod64(-ab[1], p)}), g, p)
+	}
+
+	q := big.NewInt(0).Exp(big.NewInt(p), big.NewInt(int64(d)), nil)
+
+	/* P^((q + 1) / 4) */
+	e := big.NewInt(0).Add(q, misc.One)
+	e.Rsh(e, 2)
+
+	root := polynomialBigPowModSmallInt(P, e, g, p)
+
+	if len(polynomialSubSmallInt(polynomialMulModSmallInt(root, root, g, p), P, p)) != 0 {
+		return nil, false
+	}
+
+	/* the norm from GF(p^d) down to GF(p), x^((q - 1) / (p - 1)) */
+	e.Sub(q, misc.One)
+	e.Quo(e, big.NewInt(p - 1))
+
+	norm := func(a []int64) int64 {
+		if c := polynomialBigPowModSmallInt(a, e, g, p); len(c) > 0 {
+			return c[0]
+		}
+		return 0
+	}
+
+	wanted := misc.MulModSmallInt(norm(fPrime), sqrtNorm, p)
+
+	if actual := norm(root); actual == p - wanted {
+		root = polynomialSubSmallInt([]int64{}, root, p)
+	} else if actual != wanted {
+		return nil, false
+	}
+
+	for len(root) < d {
+		root = append(root, 0)
+	}
+
+	return root, true
+}
+
+
+/* gamma(m) mod n from gamma mod each of the primes, by the chinese remainder theorem without the product of the
+primes M. M has to be well beyond twice the coefficients of gamma */
+func couveignesImage(primes []int64, roots [][]int64, m, n *big.Int) *big.Int {
+
+	d := len(roots[0])
+
+	mPowers := make([]*big.Int, d)
+	mPowers[0] = big.NewInt(1)
+	for k := 1; k < d; k += 1 {
+		mPowers[k] = big.NewInt(0).Mul(mPowers[k-1], m)
+		mPowers[k].Mod(mPowers[k], n)
+	}
+
+	/* M / p_i mod n is the product of the primes before p_i times that of the ones after it */
+	before := make([]*big.Int, len(primes) + 1)
+	after := make([]*big.Int, len(primes) + 1)
+	before[0] = big.NewInt(1)
+	after[len(primes)] = big.NewInt(1)
+
+	for i, p := range primes {
+		before[i+1] = big.NewInt(p)
+		before[i+1].Mul(before[i+1], before[i])
+		before[i+1].Mod(before[i+1], n)
+	}
+
+	for i := len(primes) - 1; i >= 0; i -= 1 {
+		after[i] = big.NewInt(primes[i])
+		after[i].Mul(after[i], after[i+1])
+		after[i].Mod(after[i], n)
+	}
+
+	ret := big.NewInt(0)
+	v := big.NewInt(0)
+	t := big.NewInt(0)
+
+	/* sum x_i / p_i for every coefficient */
+	fractions := make([]float64, d)
+
+	for i, p := range primes {
+
+		/* (M / p_i)^(-1) mod p_i */
+		inverse := int64(1)
+		for j, q := range primes {
+			if j != i {
+				inverse = misc.MulModSmallInt(inverse, q % p, p)
+			}
+		}
+		inverse = misc.ModInverseSmallInt(inverse, p)
+
+		/* x_i(m) M / p_i */
+		v.SetInt64(0)
+		for k := 0; k < d; k += 1 {
+			x := misc.MulModSmallInt(roots[i][k], inverse, p)
+			fractions[k] += float64(x) / float64(p)
+			v.Add(v, t.Mul(mPowers[k], t.SetInt64(x)))
+		}
+
+		v.Mod(v, n)
+		v.Mul(v, before[i])
+		v.Mod(v, n)
+		v.Mul(v, after[i+1])
+		ret.Add(ret, v)
+		ret.Mod(ret, n)
+	}
+
+	/* - r(m) M */
+	v.SetInt64(0)
+	for k := 0; k < d; k += 1 {
+		v.Add(v, t.Mul(mPowers[k], t.SetInt64(int64(math.Round(fractions[k])))))
+	}
+
+	v.Mul(v, before[len(primes)])
+	ret.Sub(ret, v)
+	ret.Mod(ret, n)
+
+	return ret
+}
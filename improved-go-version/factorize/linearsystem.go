@@ -4,6 +4,7 @@ package main
 
 import (
 	"fmt"
+	"math/bits"
 )
 
 
@@ -121,6 +122,17 @@ func (this Row) IsZero() bool {
 }
 
 
+/* index of the highest column set to 1, -1 if there is none */
+func (this Row) HighestColumn() int {
+	for i, chunk := range this.chunks {
+		if chunk != 0x0000000000000000 {
+			return (len(this.chunks)-1-i)*64 + 63 - bits.LeadingZeros64(chunk)
+		}
+	}
+	return -1
+}
+
+
 func (this Row) String() string {
 	var ret string
 	for i := this.columnCount - 1; i >= 0; i -= 1 {
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"math/big"
 	"os"
+	"strconv"
 )
 
 
@@ -12,9 +13,80 @@ func main() {
 	var helpText string
 	helpText += "factor [options] <min> <step>                            \n"
 	helpText += "                                                         \n"
-	helpText += "    breaks n down into two factors starting at min       \n"
+	helpText += "    breaks n down into its prime factors starting at min \n"
 	helpText += "                                                         \n"
-	helpText += "  --benchmark     print out additonal timing information \n"
+	helpText += "  --benchmark     print every split with timing          \n"
+	helpText += "                  information. the splits of the sieves  \n"
+	helpText += "                  are always printed with the relations  \n"
+	helpText += "                  found and the parameters used          \n"
+	helpText += "  --method <m>    how composites are split, one of:      \n"
+	helpText += "                    qs   hart, lehman and squfof below   \n"
+	helpText += "                         2^64, pollard rho for small     \n"
+	helpText += "                         factors, the quadratic sieve    \n"
+	helpText += "                         otherwise (default)             \n"
+	helpText += "                    rho  pollard rho (brent) only        \n"
+	helpText += "                    pm1  pollard p-1 only                \n"
+	helpText += "                    pp1  williams p+1 only               \n"
+	helpText += "                    ecm  elliptic curve method only      \n"
+	helpText += "                    gnfs as qs, but the general number   \n"
+	helpText += "                         field sieve instead of the      \n"
+	helpText += "                         quadratic sieve. n up to 30     \n"
+	helpText += "                         digits, slower than qs there    \n"
+	helpText += "  --b1 <b>        stage 1 bound of p-1 and p+1 (100000)  \n"
+	helpText += "                  and ecm (50000)                        \n"
+	helpText += "  --b2 <b>        stage 2 bound of p-1 and p+1           \n"
+	helpText += "                  (10000000) and ecm (5000000)           \n"
+	helpText += "  --curves <c>    curves ecm tries per split (300)       \n"
+	helpText += "  --seed <s>      picks the curves of ecm (0)            \n"
+	helpText += "  --fermat <i>    steps of fermat's method for close     \n"
+	helpText += "                  factors in front of qs and gnfs        \n"
+	helpText += "                  (65536). 0 disables it                 \n"
+	helpText += "  --sieve <s>     relation source, one of:               \n"
+	helpText += "                    qs    c^2 - n outward from sqrt(n)   \n"
+	helpText += "                          (default)                      \n"
+	helpText += "                    mpqs  multiple polynomials           \n"
+	helpText += "                    siqs  self-initializing multiple     \n"
+	helpText += "                          polynomials                    \n"
+	helpText += "                    cfrac continued fraction of sqrt(n)  \n"
+	helpText += "                    dixon random squares, slow, n up to  \n"
+	helpText += "                          15 digits. the factor command  \n"
+	helpText += "                          sieves from 2^64 on, so dixon  \n"
+	helpText += "                          is a reference for the tests   \n"
+	helpText += "                    mpqs and siqs are used for n >= 2^32 \n"
+	helpText += "  --solver <s>    linear algebra, one of:                \n"
+	helpText += "                    gauss    dense gaussian elimination  \n"
+	helpText += "                             (default)                   \n"
+	helpText += "                    lanczos  block lanczos               \n"
+	helpText += "                    wiedemann  block wiedemann           \n"
+	helpText += "  --filter        remove singletons, duplicates and      \n"
+	helpText += "                  cliques and merge light primes before  \n"
+	helpText += "                  the linear algebra                     \n"
+	helpText += "  --multiplier <k>                                       \n"
+	helpText += "                  sieve k n instead of n. auto picks k   \n"
+	helpText += "                  with knuth-schroeppel (default), 1     \n"
+	helpText += "                  disables it                            \n"
+	helpText += "  --large-primes <k>                                     \n"
+	helpText += "                  keep partial relations with one prime  \n"
+	helpText += "                  up to k times the largest factor base  \n"
+	helpText += "                  prime. 0 disables them                 \n"
+	helpText += "  --double-large-primes                                  \n"
+	helpText += "                  also keep partial relations with two   \n"
+	helpText += "                  large primes and combine them through  \n"
+	helpText += "                  cycles                                 \n"
+	helpText += "                                                         \n"
+	helpText += "    the following default to a table by the size of n:   \n"
+	helpText += "                                                         \n"
+	helpText += "  --factor-base <n>                                      \n"
+	helpText += "                  number of factor base primes           \n"
+	helpText += "  --block-size <m>                                       \n"
+	helpText += "                  half width of the window every         \n"
+	helpText += "                  polynomial is sieved over (mpqs, siqs) \n"
+	helpText += "                  qs sieves segments of twice that       \n"
+	helpText += "  --fudge <f>     trial divide sieve values down to f    \n"
+	helpText += "                  times log2 of the largest factor base  \n"
+	helpText += "                  prime below the expected size          \n"
+	helpText += "  --extra-relations <n>                                  \n"
+	helpText += "                  relations beyond the factor base size  \n"
 	helpText += "                                                         \n"
 	helpText += "    default is 1 1                                      \n"
 
@@ -22,7 +94,7 @@ func main() {
 
 	var min *big.Int
 	var step *big.Int
-	benchmark := false
+	opts := defaultOptions()
 
 	for i := 0; i < len(args); i++ {
 
@@ -33,7 +105,204 @@ func main() {
 
 		} else if args[i] == "--benchmark" {
 
-			benchmark = true
+			opts.benchmark = true
+
+		} else if args[i] == "--double-large-primes" {
+
+			opts.doubleLargePrimes = true
+
+		} else if args[i] == "--sieve" {
+
+			i += 1
+
+			if i < len(args) && args[i] == "qs" {
+				opts.source = sourceQS
+			} else if i < len(args) && args[i] == "mpqs" {
+				opts.source = sourceMPQS
+			} else if i < len(args) && args[i] == "siqs" {
+				opts.source = sourceSIQS
+			} else if i < len(args) && args[i] == "cfrac" {
+				opts.source = sourceCFRAC
+			} else if i < len(args) && args[i] == "dixon" {
+				opts.source = sourceDixon
+			} else {
+				fmt.Println("--sieve requires one of qs, mpqs, siqs, cfrac, dixon")
+				os.Exit(-1)
+			}
+
+		} else if args[i] == "--method" {
+
+			i += 1
+
+			if i < len(args) && args[i] == "qs" {
+				opts.method = methodQS
+			} else if i < len(args) && args[i] == "rho" {
+				opts.method = methodRho
+			} else if i < len(args) && args[i] == "pm1" {
+				opts.method = methodPMinus1
+			} else if i < len(args) && args[i] == "pp1" {
+				opts.method = methodPPlus1
+			} else if i < len(args) && args[i] == "ecm" {
+				opts.method = methodECM
+			} else if i < len(args) && args[i] == "gnfs" {
+				opts.method = methodGNFS
+			} else {
+				fmt.Println("--method requires one of qs, rho, pm1, pp1, ecm, gnfs")
+				os.Exit(-1)
+			}
+
+		} else if args[i] == "--filter" {
+
+			opts.filter = true
+
+		} else if args[i] == "--solver" {
+
+			i += 1
+
+			if i < len(args) && args[i] == "gauss" {
+				opts.solver = solverGauss
+			} else if i < len(args) && args[i] == "lanczos" {
+				opts.solver = solverLanczos
+			} else if i < len(args) && args[i] == "wiedemann" {
+				opts.solver = solverWiedemann
+			} else {
+				fmt.Println("--solver requires one of gauss, lanczos, wiedemann")
+				os.Exit(-1)
+			}
+
+		} else if args[i] == "--multiplier" {
+
+			i += 1
+
+			var err error
+
+			isAuto := i < len(args) && args[i] == "auto"
+
+			if isAuto == true {
+				opts.multiplier = autoMultiplier
+			} else if i < len(args) {
+				opts.multiplier, err = strconv.ParseInt(args[i], 10, 64)
+			}
+
+			if i >= len(args) || err != nil || (isAuto == false && opts.multiplier < 1) {
+				fmt.Println("--multiplier requires a number >= 1 or auto")
+				os.Exit(-1)
+			}
+
+		} else if args[i] == "--large-primes" {
+
+			i += 1
+
+			var err error
+
+			if i < len(args) {
+				opts.largePrimeMultiplier, err = strconv.ParseInt(args[i], 10, 64)
+			}
+
+			if i >= len(args) || err != nil || opts.largePrimeMultiplier < 0 {
+				fmt.Println("--large-primes requires a number >= 0")
+				os.Exit(-1)
+			}
+
+		} else if args[i] == "--fermat" {
+
+			i += 1
+
+			var value int64
+			var err error
+
+			if i < len(args) {
+				value, err = strconv.ParseInt(args[i], 10, 32)
+			}
+
+			if i >= len(args) || err != nil || value < 0 {
+				fmt.Println("--fermat requires a number >= 0")
+				os.Exit(-1)
+			}
+
+			opts.fermatIterations = int(value)
+
+		} else if args[i] == "--seed" {
+
+			i += 1
+
+			var err error
+
+			if i < len(args) {
+				opts.seed, err = strconv.ParseInt(args[i], 10, 64)
+			}
+
+			if i >= len(args) || err != nil {
+				fmt.Println("--seed requires a number")
+				os.Exit(-1)
+			}
+
+		} else if args[i] == "--factor-base" || args[i] == "--block-size" || args[i] == "--extra-relations" || args[i] == "--curves" {
+
+			flag := args[i]
+
+			i += 1
+
+			var value int64
+			var err error
+
+			if i < len(args) {
+				value, err = strconv.ParseInt(args[i], 10, 32)
+			}
+
+			if i >= len(args) || err != nil || value < 1 {
+				fmt.Println(flag, "requires a number >= 1")
+				os.Exit(-1)
+			}
+
+			if flag == "--factor-base" {
+				opts.factorBaseSize = int(value)
+			} else if flag == "--block-size" {
+				opts.blockSize = int(value)
+			} else if flag == "--curves" {
+				opts.curves = int(value)
+			} else {
+				opts.extraRelations = int(value)
+			}
+
+		} else if args[i] == "--b1" || args[i] == "--b2" {
+
+			flag := args[i]
+
+			i += 1
+
+			var value int64
+			var err error
+
+			if i < len(args) {
+				value, err = strconv.ParseInt(args[i], 10, 64)
+			}
+
+			if i >= len(args) || err != nil || value < 2 {
+				fmt.Println(flag, "requires a number >= 2")
+				os.Exit(-1)
+			}
+
+			if flag == "--b1" {
+				opts.B1 = value
+			} else {
+				opts.B2 = value
+			}
+
+		} else if args[i] == "--fudge" {
+
+			i += 1
+
+			var err error
+
+			if i < len(args) {
+				opts.thresholdFudge, err = strconv.ParseFloat(args[i], 64)
+			}
+
+			if i >= len(args) || err != nil || opts.thresholdFudge <= 0 {
+				fmt.Println("--fudge requires a number > 0")
+				os.Exit(-1)
+			}
 
 		} else {
 
@@ -75,7 +344,13 @@ func main() {
 
 	for i := min;; i.Add(i,step) {
 
-		factorize(i, benchmark)
+		factors, err := factorCompletely(i, opts)
+		if err != nil {
+			fmt.Println(i, "error:", err)
+			os.Exit(-1)
+		}
+
+		fmt.Println(i, "=", factorizationString(factors))
 	}
 
 }
@@ -0,0 +1,279 @@
+package main
+
+/* multiple polynomial quadratic sieve.
+ *
+ * instead of c^2 - n over one ever growing interval, many polynomials (A x + B)^2 - n = A Q(x) with
+ * Q(x) = A x^2 + 2 B x + C are sieved over the same small window x in [-M, M). choosing A close to
+ * sqrt(2n) / M keeps |Q(x)| below M sqrt(n/2) for every polynomial. */
+
+import (
+	"math"
+	"math/big"
+
+	"github.com/hydroo/quadratic-sieve/misc"
+)
+
+
+/* half width M of the window every polynomial is sieved over */
+const mpqsHalfWindow = 1 << 15
+
+/* below this size there are too few small smooth values around sqrt(n) for more than one polynomial */
+const mpqsMinBits = 32
+
+/* give up after this many polynomials, there may not be enough smooth values for tiny n */
+const mpqsMaxPolynomials = 1 << 16
+
+
+/* (A x + B)^2 - n = A Q(x). the indizes of A's factor base primes (with multiplicity) are in aFactors,
+they have to be added to the exponents of every relation */
+type polynomial struct {
+	A, B *big.Int
+	aFactors []int
+}
+
+
+func mpqs(n *big.Int, factorBase []*big.Int, M int, wanted int, thresholdFudge float64, relations *relationSet) {
+
+	primes, threshold := polynomialSievePrimes(n, factorBase, M, thresholdFudge, relations.largePrimeBits())
+
+	/* factor base index of every prime, to find A's factors */
+	fbIndex := make(map[int64]int)
+	for i := 1; i < len(factorBase); i += 1 {
+		fbIndex[factorBase[i].Int64()] = i
+	}
+
+	/* A = q^2 ~ sqrt(2n) / M */
+	q := big.NewInt(0)
+	q.Lsh(n, 1)
+	q.Sqrt(q)
+	q.Div(q, big.NewInt(int64(M)))
+	q.Sqrt(q)
+
+	logs := make([]uint8, 2*M)
+
+	for polynomials := 0; relations.count() < wanted && polynomials < mpqsMaxPolynomials; polynomials += 1 {
+
+		q = nextMPQSPrime(n, q)
+
+		poly := mpqsPolynomial(n, q)
+		if q.IsInt64() == true {
+			if i, ok := fbIndex[q.Int64()]; ok == true {
+				poly.aFactors = []int{i, i}
+			}
+		}
+
+		starts := polynomialStarts(poly, primes, factorBase, M)
+
+		sievePolynomial(n, poly, factorBase, primes, starts, M, threshold, logs, relations)
+
+		q.Add(q, misc.One)
+	}
+}
+
+
+/* smallest odd prime q >= start with n a square rest mod q */
+func nextMPQSPrime(n, start *big.Int) *big.Int {
+
+	q := big.NewInt(0).Set(start)
+
+	if q.Cmp(big.NewInt(3)) < 0 {
+		q.SetInt64(3)
+	}
+
+	/* round up to odd */
+	q.SetBit(q, 0, 1)
+
+	rest := big.NewInt(0)
+
+	for ; ; q.Add(q, misc.Two) {
+		if q.ProbablyPrime(20) == true && big.Jacobi(rest.Mod(n, q), q) == 1 {
+			return q
+		}
+	}
+}
+
+
+/* A = q^2, B^2 = n (mod A) */
+func mpqsPolynomial(n, q *big.Int) polynomial {
+
+	A := big.NewInt(0)
+	A.Mul(q, q)
+
+	/* sqrt(n) mod q lifted to mod q^2 */
+	roots := misc.SquareRootsModPrimePower(n, q, 2)
+	if len(roots) == 0 {
+		panic("mpqsPolynomial(): n is no square mod q")
+	}
+
+	B := roots[0]
+
+	check := big.NewInt(0)
+	check.Mul(B, B)
+	check.Sub(check, n)
+	if check.Mod(check, A).Sign() != 0 {
+		panic("mpqsPolynomial(): B^2 != n (mod A)")
+	}
+
+	return polynomial{A, B, []int{}}
+}
+
+
+/* sieving information and the threshold for polynomials sieved over [-M, M). the threshold is lowered by
+largePrimeBits to let partial relations through */
+func polynomialSievePrimes(n *big.Int, factorBase []*big.Int, M int, thresholdFudge, largePrimeBits float64) ([]sievePrime, uint8) {
+
+	/* |Q(x)| <= M sqrt(n/2) */
+	log2QMax := math.Log2(float64(M)) + (float64(n.BitLen()) - 1) / 2
+
+	logScale := 1.0
+	if log2QMax > 240 {
+		logScale = 240 / log2QMax
+	}
+
+	/* prime powers are not sieved, the fudge has to cover them */
+	primes := sievePrimes(n, factorBase, logScale, 0)
+
+	fudge := math.Log2(float64(primes[len(primes)-1].p)) * thresholdFudge + largePrimeBits
+
+	threshold := uint8(math.Max(0.0, (log2QMax - fudge) * logScale))
+
+	return primes, threshold
+}
+
+
+/* the positions j in [0, p) of the window for which p divides Q(-M + j), per factor base prime.
+empty for primes dividing A */
+func polynomialStarts(poly polynomial, primes []sievePrime, factorBase []*big.Int, M int) [][]int64 {
+
+	starts := make([][]int64, len(primes))
+
+	tmp := big.NewInt(0)
+
+	for i := 1; i < len(primes); i += 1 {
+
+		p := primes[i].p
+
+		aModP := tmp.Mod(poly.A, factorBase[i]).Int64()
+		if aModP == 0 {
+			starts[i] = []int64{}
+			continue
+		}
+
+		aInv := misc.ModInverseSmallInt(aModP, p)
+		bModP := tmp.Mod(poly.B, factorBase[i]).Int64()
+		mModP := int64(M) % p
+
+		/* p | Q(x) iff A x + B = r (mod p) for a root r of n */
+		starts[i] = make([]int64, 0, 2)
+		for _, r := range primes[i].roots() {
+			x := misc.MulModSmallInt((r - bModP + p) % p, aInv, p)
+			starts[i] = append(starts[i], (x + mModP) % p)
+		}
+	}
+
+	return starts
+}
+
+
+/* sieves Q(x) for x in [-M, M) and trial divides the candidates into relations. logs is the reused sieve
+array of size 2M */
+func sievePolynomial(n *big.Int, poly polynomial, factorBase []*big.Int, primes []sievePrime, starts [][]int64,
+		M int, threshold uint8, logs []uint8, relations *relationSet) {
+
+	for j := range logs {
+		logs[j] = 0
+	}
+
+	for i := 1; i < len(primes); i += 1 {
+		p := int(primes[i].p)
+		for _, start := range starts[i] {
+			for j := int(start); j < len(logs); j += p {
+				logs[j] += primes[i].logP
+			}
+		}
+	}
+
+	x := big.NewInt(0)
+	ci := big.NewInt(0)
+	di := big.NewInt(0)
+	qx := big.NewInt(0) /* to be factorized */
+	exponents := make([]int, len(factorBase))
+
+	for j, value := range logs {
+
+		if value < threshold {
+			continue
+		}
+
+		/* c(i) = A x + B, d(i) = c(i)^2 - n = A Q(x) */
+		x.SetInt64(int64(j - M))
+		ci.Mul(poly.A, x)
+		ci.Add(ci, poly.B)
+		di.Mul(ci, ci)
+		di.Sub(di, n)
+		qx.Quo(di, poly.A)
+
+		trialDivide(qx, j, factorBase, primes, starts, exponents)
+
+		for _, i := range poly.aFactors {
+			exponents[i] += 1
+		}
+
+		relations.add(ci, di, exponents, qx)
+	}
+}
+
+
+/* factorizes Q(-M + j) over the factor base into exponents. only primes whose starts match j are tried,
+and all of those dividing A (no starts). nil starts try every prime. qx is left with the part outside of
+the factor base (0 if Q is 0). returns whether qx was completely factorized */
+func trialDivide(qx *big.Int, j int, factorBase []*big.Int, primes []sievePrime, starts [][]int64, exponents []int) bool {
+
+	/* i = 0 (p = -1) needs special handling */
+	if qx.Sign() == -1 {
+		exponents[0] = 1
+		qx.Neg(qx)
+	} else {
+		exponents[0] = 0
+	}
+
+	if qx.Sign() == 0 {
+		return false
+	}
+
+	rest := big.NewInt(0)
+	quotient := big.NewInt(0)
+
+	for i := 1; i < len(factorBase); i += 1 {
+
+		exponents[i] = 0
+
+		divides := starts == nil || len(starts[i]) == 0
+		if divides == false {
+			jModP := int64(j) % primes[i].p
+			for _, start := range starts[i] {
+				if jModP == start {
+					divides = true
+				}
+			}
+		}
+
+		if divides == false {
+			continue
+		}
+
+		/* repeat as long as qx % p == 0 -> add 1 to the exponent for each division by p */
+		for {
+			quotient.QuoRem(qx, factorBase[i], rest)
+
+			if rest.Sign() == 0 {
+				exponents[i] += 1
+				qx.Set(quotient)
+			} else {
+				break
+			}
+		}
+	}
+
+	return qx.Cmp(misc.One) == 0
+}
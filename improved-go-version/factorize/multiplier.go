@@ -0,0 +1,106 @@
+package main
+
+/* knuth-schroeppel multiplier selection.
+ *
+ * sieving k n instead of n changes which primes have n as a square rest. the function rates how much
+ * small primes contribute to c^2 - k n on average, less the growth of the values by sqrt(k) */
+
+import (
+	"math"
+	"math/big"
+
+	"github.com/hydroo/quadratic-sieve/misc"
+)
+
+
+/* square free multipliers to choose from */
+var multipliers = []int64{1, 2, 3, 5, 6, 7, 10, 11, 13, 14, 15, 17, 19, 21, 22, 23, 26, 29, 30, 31, 33, 34, 35, 37, 38,
+		39, 41, 42, 43, 46, 47, 51, 53, 55, 57, 58, 59, 61, 62, 65, 66, 67, 69, 70, 71, 73}
+
+/* primes up to this are taken into account */
+const knuthSchroeppelBound = 2000
+
+/* options.multiplier: pick one with knuthSchroeppel() */
+const autoMultiplier = -1
+
+
+func knuthSchroeppel(n *big.Int) int64 {
+
+	best := int64(1)
+	bestScore := math.Inf(-1)
+
+	for _, k := range multipliers {
+		if score := knuthSchroeppelScore(n, k); score > bestScore {
+			best = k
+			bestScore = score
+		}
+	}
+
+	return best
+}
+
+
+/* expected contribution of the small primes to log|c^2 - k n| less the growth by sqrt(k) */
+func knuthSchroeppelScore(n *big.Int, k int64) float64 {
+
+	kn := big.NewInt(0)
+	kn.Mul(n, big.NewInt(k))
+
+	rest := big.NewInt(0)
+	P := big.NewInt(0)
+
+	score := -0.5 * math.Log(float64(k))
+
+	/* p = 2 divides c^2 - k n depending on k n mod 8 */
+	switch rest.Mod(kn, big.NewInt(8)).Int64() {
+	case 1:
+		score += 2.0 * math.Log(2.0)
+	case 5:
+		score += math.Log(2.0)
+	case 3, 7:
+		score += 0.5 * math.Log(2.0)
+	}
+
+	/* the odd primes */
+	for _, p := range misc.Primes(knuthSchroeppelBound)[1:] {
+
+		logP := math.Log(float64(p))
+
+		if k % p == 0 {
+			/* one root, p | c */
+			score += logP / float64(p)
+		} else if misc.LegendreSmallInt(rest.Mod(kn, P.SetInt64(p)).Int64(), p) == 1 {
+			/* two roots, each dividing c^2 - k n by p^(1 + 1/p + ...) on average */
+			score += 2.0 * logP / float64(p - 1)
+		}
+	}
+
+	return score
+}
+
+
+/* the factors of n from a split x * y of a multiple of n. nil, nil if it is trivial */
+func factorsOfN(n, x, y *big.Int) (*big.Int, *big.Int) {
+
+	if x == nil || y == nil {
+		return nil, nil
+	}
+
+	product := big.NewInt(0)
+	if product.Mul(x, y).Cmp(n) == 0 {
+		return x, y
+	}
+
+	g := big.NewInt(0)
+	g.GCD(nil, nil, x, n)
+
+	if g.Cmp(misc.One) == 0 || g.Cmp(n) == 0 {
+		g.GCD(nil, nil, y, n)
+	}
+
+	if g.Cmp(misc.One) == 0 || g.Cmp(n) == 0 {
+		return nil, nil
+	}
+
+	return g, big.NewInt(0).Quo(n, g)
+}
@@ -0,0 +1,118 @@
+package main
+
+/* sieve parameters by the size of n.
+ *
+ * the L(n) formulas (wikipedia) ask for far more factor base primes than pay off at the sizes the
+ * multiple polynomial sieves are run at. they are only used below the table now, for tiny n. every
+ * value can be overridden from the command line */
+
+import (
+	"fmt"
+	"math/big"
+)
+
+
+type parameters struct {
+	factorBaseSize int /* primes in the factor base besides -1. 0: bound from L(n) */
+	blockSize int /* half width M of the window every polynomial is sieved over (mpqs, siqs), qs sieves segments of 2M
+			positions */
+	thresholdFudge float64 /* sieve values this many times log2(largest factor base prime) below log2|d| are trial divided */
+	largePrimeMultiplier int64 /* large primes up to this times the largest factor base prime, 0 disables them */
+	extraRelations int /* relations beyond the size of the factor base, to get a few dependencies */
+}
+
+
+/* by decimal digits of (k times) n. the first row with at least as many digits applies, the last one
+beyond that */
+var parameterTable = []struct {
+	digits int
+	params parameters
+}{
+	{20, parameters{100, 1 << 15, 1.0, 32, 20}},
+	{25, parameters{150, 1 << 15, 1.0, 32, 20}},
+	{30, parameters{200, 1 << 15, 1.0, 32, 30}},
+	{35, parameters{350, 1 << 15, 1.0, 32, 40}},
+	{40, parameters{600, 1 << 15, 1.0, 32, 50}},
+	{45, parameters{1200, 1 << 15, 1.0, 32, 60}},
+	{50, parameters{2000, 1 << 15, 1.2, 64, 64}},
+	{55, parameters{3200, 1 << 16, 1.2, 64, 64}},
+	{60, parameters{5000, 1 << 16, 1.2, 100, 64}},
+	{65, parameters{7500, 1 << 16, 1.2, 100, 64}},
+	{70, parameters{11000, 1 << 17, 1.3, 128, 64}},
+	{75, parameters{16000, 1 << 17, 1.3, 128, 64}},
+	{80, parameters{24000, 1 << 17, 1.3, 128, 64}},
+}
+
+
+/* factor base sizes of dixon's random squares, by decimal digits of n like parameterTable. its values are as large
+as n, the sieves' factor bases are far too small to find smooth ones among them. beyond the last row dixon takes
+too long, factorize() refuses */
+var dixonParameterTable = []struct {
+	digits int
+	factorBaseSize int
+}{
+	{8, 30},
+	{10, 60},
+	{12, 150},
+	{15, 400},
+}
+
+
+/* below the table */
+var defaultParameters = parameters{0, mpqsHalfWindow, sieveThresholdFudge, defaultLargePrimeMultiplier, extraRelations}
+
+
+/* options.largePrimeMultiplier: take it from parametersFor() */
+const tableLargePrimeMultiplier = -1
+
+
+/* the table row for n with the overrides from opts applied. zero overrides are not set */
+func parametersFor(n *big.Int, opts options) parameters {
+
+	ret := defaultParameters
+
+	digits := len(n.String())
+
+	if digits >= parameterTable[0].digits {
+		ret = parameterTable[len(parameterTable)-1].params
+		for _, row := range parameterTable {
+			if row.digits >= digits {
+				ret = row.params
+				break
+			}
+		}
+	}
+
+	if opts.source == sourceDixon {
+		for _, row := range dixonParameterTable {
+			if row.digits >= digits {
+				ret.factorBaseSize = row.factorBaseSize
+				break
+			}
+		}
+	}
+
+	if opts.factorBaseSize > 0 {
+		ret.factorBaseSize = opts.factorBaseSize
+	}
+	if opts.blockSize > 0 {
+		ret.blockSize = opts.blockSize
+	}
+	if opts.thresholdFudge > 0 {
+		ret.thresholdFudge = opts.thresholdFudge
+	}
+	if opts.largePrimeMultiplier != tableLargePrimeMultiplier {
+		ret.largePrimeMultiplier = opts.largePrimeMultiplier
+	}
+	if opts.extraRelations > 0 {
+		ret.extraRelations = opts.extraRelations
+	}
+
+	return ret
+}
+
+
+func (this parameters) String() string {
+	return fmt.Sprint("factor-base ", this.factorBaseSize, " block-size ", this.blockSize, " fudge ", this.thresholdFudge,
+			" large-primes ", this.largePrimeMultiplier, " extra-relations ", this.extraRelations)
+}
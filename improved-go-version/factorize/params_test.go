@@ -0,0 +1,42 @@
+package main
+
+
+import (
+	"math/big"
+	"testing"
+)
+
+
+func TestParametersFor(t *testing.T) {
+
+	small := big.NewInt(2626849055875147)
+	if params := parametersFor(small, options{largePrimeMultiplier: tableLargePrimeMultiplier}); params != defaultParameters {
+		t.Error("parameters below the table", params, "!=", defaultParameters)
+	}
+
+	n, _ := big.NewInt(0).SetString("6734319982431950692508617574486143524749", 10)
+
+	params := parametersFor(n, options{largePrimeMultiplier: tableLargePrimeMultiplier})
+	if params != parameterTable[4].params {
+		t.Error("parameters for 40 digits", params, "!=", parameterTable[4].params)
+	}
+
+	huge := big.NewInt(0).Exp(big.NewInt(10), big.NewInt(200), nil)
+	if params := parametersFor(huge, options{largePrimeMultiplier: tableLargePrimeMultiplier}); params != parameterTable[len(parameterTable)-1].params {
+		t.Error("parameters beyond the table", params)
+	}
+
+	/* dixon takes its own factor base size */
+	if params := parametersFor(big.NewInt(40198364677), options{source: sourceDixon}); params.factorBaseSize != dixonParameterTable[2].factorBaseSize {
+		t.Error("dixon's factor base for 11 digits", params.factorBaseSize, "!=", dixonParameterTable[2].factorBaseSize)
+	}
+
+	opts := options{factorBaseSize: 123, blockSize: 4567, thresholdFudge: 0.5, largePrimeMultiplier: 0, extraRelations: 9}
+	should := parameters{123, 4567, 0.5, 0, 9}
+	if params := parametersFor(n, opts); params != should {
+		t.Error("overridden parameters", params, "!=", should)
+	}
+
+	testFactorizeSplits(t, []string{n.String()}, options{source: sourceSIQS, largePrimeMultiplier: tableLargePrimeMultiplier,
+			factorBaseSize: 400, blockSize: 1 << 14, thresholdFudge: 1.3, extraRelations: 40})
+}
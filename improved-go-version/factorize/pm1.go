@@ -0,0 +1,152 @@
+package main
+
+/* pollard's p-1 method.
+ *
+ * if p | n and p - 1 divides E, then a^E = 1 (mod p) for every a coprime to p and gcd(a^E - 1, n) reveals p.
+ * stage 1 takes E as the product of the largest powers of all primes up to B1 that are not above B1.
+ * stage 2 catches p - 1 = s q with B1-smooth s and one more prime B1 < q <= B2: with b = a^E it walks b^q
+ * along the primes q. the step from one prime to the next is a power b^d from a table over the prime gaps d. */
+
+import (
+	"math/big"
+
+	"github.com/hydroo/quadratic-sieve/misc"
+)
+
+
+/* bounds unless given on the command line */
+const pm1DefaultB1 = 100000
+const pm1DefaultB2 = 100 * pm1DefaultB1
+
+/* stage 2 multiplies the candidates up mod n and takes a gcd every this many primes */
+const stage2Batch = 256
+
+
+/* a non-trivial factor of n or nil. B1 <= 0 and B2 <= 0 take the defaults, B2 <= B1 skips stage 2 */
+func PollardPMinus1(n *big.Int, B1, B2 int64) *big.Int {
+
+	B1, B2 = stageBounds(B1, B2, pm1DefaultB1, pm1DefaultB2)
+
+	primes := misc.Primes(B2)
+
+	/* stage 1: a = 2^E */
+	a := big.NewInt(2)
+	Q := big.NewInt(0)
+
+	for _, p := range primes {
+		if p > B1 {
+			break
+		}
+		Q.SetInt64(largestPowerUpTo(p, B1))
+		a.Exp(a, Q, n)
+	}
+
+	g := big.NewInt(0)
+	g.Sub(a, misc.One)
+	g.GCD(nil, nil, g, n)
+
+	if g.Cmp(misc.One) != 0 {
+		/* g = n: every prime of n was found at once, a finer stage 1 would be needed */
+		return nonTrivialFactor(g, n)
+	}
+
+	/* stage 2: b^q - 1 for b = a^E and B1 < q <= B2 */
+	b := big.NewInt(0)
+	value := big.NewInt(0)
+	gapPowers := make(map[int64]*big.Int) /* prime gap d -> a^d */
+
+	return stage2(n, primes, B1,
+		func(q int64) {
+			b.Exp(a, Q.SetInt64(q), n)
+		},
+		func(d int64) {
+			if _, ok := gapPowers[d]; ok == false {
+				gapPowers[d] = big.NewInt(0).Exp(a, big.NewInt(d), n)
+			}
+			b.Mul(b, gapPowers[d])
+			b.Mod(b, n)
+		},
+		func() *big.Int {
+			return value.Sub(b, misc.One)
+		})
+}
+
+
+/* the defaults for bounds <= 0, B2 no lower than B1 */
+func stageBounds(B1, B2, defaultB1, defaultB2 int64) (int64, int64) {
+
+	if B1 <= 0 {
+		B1 = defaultB1
+	}
+
+	if B2 <= 0 {
+		B2 = defaultB2
+	}
+
+	if B2 < B1 {
+		B2 = B1
+	}
+
+	return B1, B2
+}
+
+
+/* p^k <= bound < p^(k+1) */
+func largestPowerUpTo(p, bound int64) int64 {
+
+	q := p
+	for q <= bound / p {
+		q *= p
+	}
+
+	return q
+}
+
+
+/* g if it is a non-trivial factor of n, nil otherwise */
+func nonTrivialFactor(g, n *big.Int) *big.Int {
+
+	if g.Cmp(misc.One) == 0 || g.Cmp(n) == 0 {
+		return nil
+	}
+
+	return g
+}
+
+
+/* the prime gap walk over the primes B1 < q <= B2 shared by p-1 and p+1. start sets the current element to the one
+for the first prime q, step moves it on by d, value is the number whose gcd with n may reveal a factor */
+func stage2(n *big.Int, primes []int64, B1 int64, start func(q int64), step func(d int64), value func() *big.Int) *big.Int {
+
+	product := big.NewInt(1)
+	g := big.NewInt(0)
+
+	previous := int64(0)
+	count := 0
+
+	for _, q := range primes {
+
+		if q <= B1 {
+			continue
+		}
+
+		if previous == 0 {
+			start(q)
+		} else {
+			step(q - previous)
+		}
+		previous = q
+
+		product.Mul(product, value())
+		product.Mod(product, n)
+
+		count += 1
+		if count % stage2Batch == 0 {
+			if g.GCD(nil, nil, product, n).Cmp(misc.One) != 0 {
+				return nonTrivialFactor(g, n)
+			}
+		}
+	}
+
+	return nonTrivialFactor(g.GCD(nil, nil, product, n), n)
+}
@@ -0,0 +1,96 @@
+package main
+
+
+import (
+	"math/big"
+	"testing"
+)
+
+
+/* p - 1 = 2 * 65537 * (primes below 1000), p + 1 = 2 * 99991 * (primes below 1000), and a safe prime r, where
+neither r - 1 nor r + 1 is smooth */
+const pm1Prime = "1386192949662615659723"
+const pp1Prime = "753202601995141626973"
+const safePrime = "1208925819614629174708367"
+
+
+func smoothTimesSafe(t *testing.T, p string) (*big.Int, *big.Int) {
+
+	P, ok1 := big.NewInt(0).SetString(p, 10)
+	R, ok2 := big.NewInt(0).SetString(safePrime, 10)
+	if ok1 == false || ok2 == false {
+		t.Fatal("not a number:", p, safePrime)
+	}
+
+	return P, big.NewInt(0).Mul(P, R)
+}
+
+
+func TestPollardPMinus1(t *testing.T) {
+
+	p, n := smoothTimesSafe(t, pm1Prime)
+
+	/* in stage 1 alone, then in stage 2 */
+	for _, bounds := range [][2]int64{{100000, 100000}, {1000, 100000}} {
+		if f := PollardPMinus1(n, bounds[0], bounds[1]); f == nil || f.Cmp(p) != 0 {
+			t.Error("PollardPMinus1(", n, ",", bounds[0], ",", bounds[1], ") =", f, "instead of", p)
+		}
+	}
+
+	/* 65537 is beyond both bounds */
+	if f := PollardPMinus1(n, 1000, 10000); f != nil {
+		t.Error("PollardPMinus1(", n, ", 1000, 10000) found", f, "beyond its bounds")
+	}
+
+	factors, err := factorCompletely(n, options{method: methodPMinus1, B1: 1000, B2: 100000})
+	if err != nil {
+		t.Error(n, err)
+	} else {
+		testFactorization(t, n, factors)
+	}
+}
+
+
+func TestWilliamsPPlus1(t *testing.T) {
+
+	p, n := smoothTimesSafe(t, pp1Prime)
+
+	for _, bounds := range [][2]int64{{100000, 100000}, {1000, 100000}} {
+		if f := WilliamsPPlus1(n, bounds[0], bounds[1]); f == nil || f.Cmp(p) != 0 {
+			t.Error("WilliamsPPlus1(", n, ",", bounds[0], ",", bounds[1], ") =", f, "instead of", p)
+		}
+	}
+
+	/* p - 1 is not smooth, p + 1 is */
+	if f := PollardPMinus1(n, 1000, 100000); f != nil {
+		t.Error("PollardPMinus1(", n, ", 1000, 100000) found", f)
+	}
+
+	if f := WilliamsPPlus1(n, 1000, 10000); f != nil {
+		t.Error("WilliamsPPlus1(", n, ", 1000, 10000) found", f, "beyond its bounds")
+	}
+
+	factors, err := factorCompletely(n, options{method: methodPPlus1, B1: 1000, B2: 100000})
+	if err != nil {
+		t.Error(n, err)
+	} else {
+		testFactorization(t, n, factors)
+	}
+}
+
+
+func TestLucasV(t *testing.T) {
+
+	/* V_k(3) = 2, 3, 7, 18, 47, 123, 322, 843, ... */
+	n := big.NewInt(1000000007)
+	expected := []int64{3, 7, 18, 47, 123, 322, 843}
+
+	v := big.NewInt(0)
+
+	for k, e := range expected {
+		lucasV(v, big.NewInt(3), int64(k+1), n)
+		if v.Int64() != e {
+			t.Error("V_", k+1, "(3) =", v, "instead of", e)
+		}
+	}
+}
@@ -0,0 +1,182 @@
+package main
+
+/* williams' p+1 method.
+ *
+ * for A with A^2 - 4 a non-residue mod p, a root x of x^2 - A x + 1 lives in GF(p^2) and has order dividing
+ * p + 1, so with p + 1 | E, x^E = 1 and the trace V_E = x^E + x^-E = 2 (mod p). stage 1 gets V_E from the lucas
+ * sequence V_0 = 2, V_1 = A, V_2k = V_k^2 - 2, V_2k+1 = V_k V_k+1 - A, for E as in p-1. stage 2 works on
+ * y = x^E, a root of y^2 - V_E y + 1, with the prime gap walk of p-1: y^q is stepped along the primes q in the
+ * ring Z_n[y] / (y^2 - V_E y + 1), and the trace of y^q minus 2 goes into the gcd.
+ *
+ * if A^2 - 4 is a residue mod p instead, the same finds p when p - 1 is smooth. p is unknown, so several A are
+ * tried. */
+
+import (
+	"math/big"
+	"math/bits"
+
+	"github.com/hydroo/quadratic-sieve/misc"
+)
+
+
+/* A = numerator / denominator mod n, the usual seeds after montgomery */
+var pp1Seeds = [][2]int64{{2, 7}, {6, 5}, {3, 1}}
+
+
+/* a non-trivial factor of n or nil. the bounds are those of PollardPMinus1() */
+func WilliamsPPlus1(n *big.Int, B1, B2 int64) *big.Int {
+
+	B1, B2 = stageBounds(B1, B2, pm1DefaultB1, pm1DefaultB2)
+
+	primes := misc.Primes(B2)
+
+	A := big.NewInt(0)
+	g := big.NewInt(0)
+	Q := big.NewInt(0)
+
+	for _, seed := range pp1Seeds {
+
+		A.SetInt64(seed[1])
+		if g.GCD(nil, nil, A, n).Cmp(misc.One) != 0 {
+			return nonTrivialFactor(g, n)
+		}
+		A.ModInverse(A, n)
+		A.Mul(A, Q.SetInt64(seed[0]))
+		A.Mod(A, n)
+
+		/* stage 1: V_E */
+		v := big.NewInt(0).Set(A)
+
+		for _, p := range primes {
+			if p > B1 {
+				break
+			}
+			lucasV(v, v, largestPowerUpTo(p, B1), n)
+		}
+
+		g.Sub(v, misc.Two)
+		g.GCD(nil, nil, g, n)
+
+		if g.Cmp(n) == 0 {
+			/* every prime of n at once, maybe not with the next A */
+			continue
+		} else if g.Cmp(misc.One) != 0 {
+			return g
+		}
+
+		/* stage 2: trace(y^q) - 2 for y^2 = V_E y - 1 and B1 < q <= B2 */
+		y := quadraticElement{big.NewInt(0), big.NewInt(1)}
+		b := quadraticElement{big.NewInt(0), big.NewInt(0)}
+		value := big.NewInt(0)
+		gapPowers := make(map[int64]quadraticElement) /* prime gap d -> y^d */
+
+		f := stage2(n, primes, B1,
+			func(q int64) {
+				b.pow(y, q, v, n)
+			},
+			func(d int64) {
+				if _, ok := gapPowers[d]; ok == false {
+					gapPowers[d] = quadraticElement{big.NewInt(0), big.NewInt(0)}
+					gapPowers[d].pow(y, d, v, n)
+				}
+				b.mul(b, gapPowers[d], v, n)
+			},
+			func() *big.Int {
+				return b.trace(value, v, n).Sub(value, misc.Two)
+			})
+
+		if f != nil {
+			return f
+		}
+	}
+
+	return nil
+}
+
+
+/* sets r = V_k(A) mod n, r and A may be the same */
+func lucasV(r, A *big.Int, k int64, n *big.Int) {
+
+	/* x = V_j, y = V_j+1 for the leading bits j of k */
+	x := big.NewInt(0).Set(A)
+	y := big.NewInt(0).Mul(A, A)
+	y.Sub(y, misc.Two)
+	y.Mod(y, n)
+
+	for i := bits.Len64(uint64(k)) - 2; i >= 0; i -= 1 {
+
+		if (k >> uint(i)) & 1 == 1 {
+			x.Mul(x, y)
+			x.Sub(x, A)
+			x.Mod(x, n)
+			y.Mul(y, y)
+			y.Sub(y, misc.Two)
+			y.Mod(y, n)
+		} else {
+			y.Mul(x, y)
+			y.Sub(y, A)
+			y.Mod(y, n)
+			x.Mul(x, x)
+			x.Sub(x, misc.Two)
+			x.Mod(x, n)
+		}
+	}
+
+	r.Set(x)
+}
+
+
+/* *** quadraticElement *** ************************************************ */
+
+/* c0 + c1 y in Z_n[y] / (y^2 - A y + 1) */
+type quadraticElement struct {
+	c0, c1 *big.Int
+}
+
+
+/* this = a b. a and b may be this */
+func (this quadraticElement) mul(a, b quadraticElement, A, n *big.Int) {
+
+	/* (a0 + a1 y)(b0 + b1 y) = a0 b0 - a1 b1 + (a0 b1 + a1 b0 + a1 b1 A) y */
+	a1b1 := big.NewInt(0).Mul(a.c1, b.c1)
+	a1b1.Mod(a1b1, n)
+
+	c0 := big.NewInt(0).Mul(a.c0, b.c0)
+	c0.Sub(c0, a1b1)
+	c0.Mod(c0, n)
+
+	c1 := big.NewInt(0).Mul(a.c0, b.c1)
+	c1.Add(c1, big.NewInt(0).Mul(a.c1, b.c0))
+	c1.Add(c1, a1b1.Mul(a1b1, A))
+	c1.Mod(c1, n)
+
+	this.c0.Set(c0)
+	this.c1.Set(c1)
+}
+
+
+/* this = a^k for k > 0. a must not be this */
+func (this quadraticElement) pow(a quadraticElement, k int64, A, n *big.Int) {
+
+	this.c0.Set(a.c0)
+	this.c1.Set(a.c1)
+
+	for i := bits.Len64(uint64(k)) - 2; i >= 0; i -= 1 {
+		this.mul(this, this, A, n)
+		if (k >> uint(i)) & 1 == 1 {
+			this.mul(this, a, A, n)
+		}
+	}
+}
+
+
+/* r = this + this^-1 = 2 c0 + c1 A mod n */
+func (this quadraticElement) trace(r, A, n *big.Int) *big.Int {
+
+	r.Mul(this.c1, A)
+	r.Add(r, this.c0)
+	r.Add(r, this.c0)
+	r.Mod(r, n)
+
+	return r
+}
@@ -0,0 +1,321 @@
+package main
+
+/* collecting relations c(i)^2 - n = d(i) from the sieves.
+ *
+ * a full relation factorizes d(i) completely over the factor base. a partial relation leaves one
+ * large prime L outside of it, a partial-partial relation two of them, L1 L2.
+ *
+ * partial relations are edges in a graph whose vertices are the large primes, with 1 as the second
+ * vertex of partials with only one large prime. every vertex on a cycle in this graph has two edges,
+ * so the product of the cycle's relations has every large prime squared: (c1 ... ck)^2 = d1 ... dk (mod n)
+ * and d1 ... dk / (L1 ... Lk)^2 factorizes over the factor base. two partials with the same L are the
+ * shortest cycle 1 - L - 1.
+ *
+ * the graph is kept as a spanning forest (union-find for the components). an edge between two vertices
+ * of the same component closes a cycle with the forest path between them. */
+
+import (
+	"math"
+	"math/big"
+
+	"github.com/hydroo/quadratic-sieve/misc"
+)
+
+
+/* a relation with large primes outside of the factor base. there are many more partial than full
+relations, so the factorization is kept sparse: the factor base indizes, repeated by their exponents */
+type partialRelation struct {
+	ci, di *big.Int
+	factors []int
+}
+
+
+/* an edge of the spanning forest, to the vertex 'to' through partial relation 'relation' */
+type graphEdge struct {
+	to int64
+	relation int
+}
+
+
+/* partial-partial cofactors are let through the sieve up to this power of the largest factor base prime
+(on top of the multipliers). splitting cofactors is expensive and pays off only for large n */
+const doubleLargePrimeExponent = 0.4
+
+
+type relationSet struct {
+
+	n *big.Int
+	factorBaseSize int
+
+	/* full relations (including those from cycles), ready for linearSystemFromExponents().
+	cycles only satisfy c(i)^2 = d(i) (mod n) */
+	cis, dis []*big.Int
+	exponents [][]int
+
+	/* partial relations are kept if their large primes are at most largePrimeMultiplier times the
+	largest factor base prime. 0 disables them */
+	largePrimeMultiplier int64
+	largestPrime, largePrimeBound int64
+	doubleLargePrimes bool
+
+	partials []partialRelation
+
+	/* union-find over the large primes and the spanning forest with all partials that didn't close a cycle */
+	parent map[int64]int64
+	forest map[int64][]graphEdge
+
+	/* |c(i)| of every relation seen, different polynomials can produce the same one */
+	seen map[string]bool
+
+	fulls, partialCount, partialPartialCount, cycles int
+}
+
+
+func newRelationSet(n *big.Int, factorBase []*big.Int, largePrimeMultiplier int64, doubleLargePrimes bool) *relationSet {
+
+	var ret relationSet
+	ret.n = n
+	ret.factorBaseSize = len(factorBase)
+	ret.cis = make([]*big.Int, 0)
+	ret.dis = make([]*big.Int, 0)
+	ret.exponents = make([][]int, 0)
+	ret.largePrimeMultiplier = largePrimeMultiplier
+	ret.largestPrime = factorBase[len(factorBase)-1].Int64()
+	ret.largePrimeBound = ret.largestPrime * largePrimeMultiplier
+	ret.doubleLargePrimes = doubleLargePrimes && largePrimeMultiplier > 0
+	ret.partials = make([]partialRelation, 0)
+	ret.parent = make(map[int64]int64)
+	ret.forest = make(map[int64][]graphEdge)
+	ret.seen = make(map[string]bool)
+
+	if ret.largePrimeBound > math.MaxInt32 {
+		/* products of two large primes have to fit into an int64 */
+		ret.largePrimeBound = math.MaxInt32
+	}
+
+	return &ret
+}
+
+
+/* number of usable (full and cycle) relations */
+func (this relationSet) count() int {
+	return len(this.cis)
+}
+
+
+/* how many more bits below log2|d(i)| the sieve value may be to still yield a partial relation. the sieve
+fudge already allows for about one largest factor base prime */
+func (this relationSet) largePrimeBits() float64 {
+
+	if this.largePrimeMultiplier <= 1 {
+		return 0.0
+	}
+
+	if this.doubleLargePrimes == true {
+		/* the full L1 L2 <= bound^2 lets through far too many candidates that don't split, most
+		partial-partials are well below it */
+		return 2 * math.Log2(float64(this.largePrimeMultiplier)) + doubleLargePrimeExponent * math.Log2(float64(this.largestPrime))
+	}
+
+	return math.Log2(float64(this.largePrimeMultiplier))
+}
+
+
+/* adds c(i)^2 - n = d(i). exponents hold the factor base part of d(i), cofactor the rest.
+everything is copied, the caller may reuse its variables */
+func (this *relationSet) add(ci, di *big.Int, exponents []int, cofactor *big.Int) {
+
+	if cofactor.Sign() == 0 {
+		return
+	}
+
+	isFull := cofactor.Cmp(misc.One) == 0
+
+	var l1, l2 int64
+
+	if isFull == false {
+		var ok bool
+		if l1, l2, ok = this.largePrimes(cofactor); ok == false {
+			return
+		}
+	}
+
+	key := big.NewInt(0).Abs(ci).String()
+	if this.seen[key] == true {
+		return
+	}
+	this.seen[key] = true
+
+	if isFull == true {
+		exponentsCopy := make([]int, len(exponents))
+		copy(exponentsCopy, exponents)
+
+		this.cis = append(this.cis, big.NewInt(0).Set(ci))
+		this.dis = append(this.dis, big.NewInt(0).Set(di))
+		this.exponents = append(this.exponents, exponentsCopy)
+		this.fulls += 1
+		return
+	}
+
+	if l2 == 1 {
+		this.partialCount += 1
+	} else {
+		this.partialPartialCount += 1
+	}
+
+	factors := []int{}
+	for i, e := range exponents {
+		for k := 0; k < e; k += 1 {
+			factors = append(factors, i)
+		}
+	}
+
+	this.partials = append(this.partials, partialRelation{big.NewInt(0).Set(ci), big.NewInt(0).Set(di), factors})
+
+	this.addEdge(l1, l2, len(this.partials)-1)
+}
+
+
+/* splits the cofactor into one or two large primes within the bound. the second one is 1 for a single one */
+func (this relationSet) largePrimes(cofactor *big.Int) (int64, int64, bool) {
+
+	if cofactor.IsInt64() == false {
+		return 0, 0, false
+	}
+
+	m := cofactor.Int64()
+
+	/* primes outside of the factor base cannot divide d(i), so anything below the square of the
+	largest factor base prime is a prime */
+	isPrime := m / this.largestPrime < this.largestPrime || cofactor.ProbablyPrime(20) == true
+
+	if m <= this.largePrimeBound && isPrime == true {
+		return m, 1, true
+	}
+
+	if this.doubleLargePrimes == false || isPrime == true || m / this.largePrimeBound > this.largePrimeBound {
+		return 0, 0, false
+	}
+
+	f, _ := splitSmallInt(uint64(m))
+	l1 := int64(f)
+
+	if l1 == 0 {
+		return 0, 0, false
+	}
+
+	l2 := m / l1
+
+	/* a factor larger than the bound can be composite, but then the other one is too small to be a prime */
+	if l1 > this.largePrimeBound || l2 > this.largePrimeBound {
+		return 0, 0, false
+	}
+
+	return l1, l2, true
+}
+
+
+func (this *relationSet) find(v int64) int64 {
+
+	root := v
+	for {
+		parent, ok := this.parent[root]
+		if ok == false || parent == root {
+			break
+		}
+		root = parent
+	}
+
+	/* path compression */
+	for v != root {
+		next := this.parent[v]
+		this.parent[v] = root
+		v = next
+	}
+
+	return root
+}
+
+
+func (this *relationSet) addEdge(u, v int64, relation int) {
+
+	if u == v {
+		/* L^2 is a square already */
+		this.addCycle([]int{relation})
+		return
+	}
+
+	rootU := this.find(u)
+	rootV := this.find(v)
+
+	if rootU != rootV {
+		this.parent[rootU] = rootV
+		this.forest[u] = append(this.forest[u], graphEdge{v, relation})
+		this.forest[v] = append(this.forest[v], graphEdge{u, relation})
+		return
+	}
+
+	this.addCycle(append(this.forestPath(u, v), relation))
+}
+
+
+/* the partial relations on the path from u to v in the spanning forest (breadth first search) */
+func (this relationSet) forestPath(u, v int64) []int {
+
+	/* the vertex and edge each vertex has been reached from */
+	reachedBy := make(map[int64]graphEdge)
+	reachedBy[u] = graphEdge{u, -1}
+
+	queue := []int64{u}
+
+	for len(queue) > 0 {
+
+		w := queue[0]
+		queue = queue[1:]
+
+		if w == v {
+			break
+		}
+
+		for _, edge := range this.forest[w] {
+			if _, ok := reachedBy[edge.to]; ok == false {
+				reachedBy[edge.to] = graphEdge{w, edge.relation}
+				queue = append(queue, edge.to)
+			}
+		}
+	}
+
+	path := []int{}
+	for w := v; w != u; w = reachedBy[w].to {
+		path = append(path, reachedBy[w].relation)
+	}
+
+	return path
+}
+
+
+/* multiplies the partial relations of a cycle to a full relation */
+func (this *relationSet) addCycle(relations []int) {
+
+	ci := big.NewInt(1)
+	di := big.NewInt(1)
+	exponents := make([]int, this.factorBaseSize)
+
+	for _, r := range relations {
+
+		partial := this.partials[r]
+
+		ci.Mul(ci, partial.ci)
+		ci.Mod(ci, this.n)
+		di.Mul(di, partial.di)
+
+		for _, i := range partial.factors {
+			exponents[i] += 1
+		}
+	}
+
+	this.cis = append(this.cis, ci)
+	this.dis = append(this.dis, di)
+	this.exponents = append(this.exponents, exponents)
+	this.cycles += 1
+}
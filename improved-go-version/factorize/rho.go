@@ -0,0 +1,189 @@
+package main
+
+/* pollard rho with brent's cycle detection.
+ *
+ * x -> x^2 + c (mod n) runs into a cycle mod every prime p | n after about sqrt(p) steps, long before it does
+ * mod n. brent compares x_i to the x_(2^k - 1) before it instead of floyd's x_(2i), and the differences are
+ * multiplied up mod n so only every rhoBatch-th step needs a gcd. if a batch overshoots to gcd = n, it is
+ * replayed one step at a time.
+ *
+ * every routine comes twice: a fast path for n < 2^64 (...SmallInt) and a *big.Int path. */
+
+import (
+	"math/big"
+	"math/bits"
+
+	"github.com/hydroo/quadratic-sieve/misc"
+)
+
+
+/* steps between gcds */
+const rhoBatch = 128
+
+/* polynomials x^2 + c tried before giving up */
+const rhoAttempts = 20
+
+/* steps the big path makes in front of the quadratic sieve, enough for factors of about 32 bits */
+const rhoPresieveIterations = 1 << 16
+
+
+/* *** native integers *** ************************************************* */
+
+/* a non-trivial factor of the composite n or 0. primes take about sqrt(n) steps per polynomial to give up */
+func pollardRhoSmallInt(n uint64) uint64 {
+
+	if n % 2 == 0 {
+		if n > 2 {
+			return 2
+		}
+		return 0
+	}
+
+	mulMod := func(a, b uint64) uint64 {
+		hi, lo := bits.Mul64(a, b)
+		return bits.Rem64(hi, lo, n)
+	}
+
+	gcd := func(a, b uint64) uint64 {
+		for b != 0 {
+			a, b = b, a % b
+		}
+		return a
+	}
+
+	distance := func(a, b uint64) uint64 {
+		if a < b {
+			return b - a
+		}
+		return a - b
+	}
+
+	for c := uint64(1); c <= rhoAttempts; c += 1 {
+
+		f := func(y uint64) uint64 {
+			s := mulMod(y, y)
+			if t := s + c; t < s || t >= n {
+				/* overflowed or not reduced */
+				return t - n
+			} else {
+				return t
+			}
+		}
+
+		x, y, ys := uint64(0), uint64(2), uint64(0)
+		g, q := uint64(1), uint64(1)
+
+		for r := 1; g == 1; r *= 2 {
+
+			x = y
+			for i := 0; i < r; i += 1 {
+				y = f(y)
+			}
+
+			/* batch the gcds */
+			for k := 0; k < r && g == 1; k += rhoBatch {
+				ys = y
+				for i := 0; i < rhoBatch && i < r - k; i += 1 {
+					y = f(y)
+					q = mulMod(q, distance(x, y))
+				}
+				g = gcd(q, n)
+			}
+		}
+
+		if g == n {
+			/* the batch overshot, step back one by one */
+			for g = 1; g == 1; {
+				ys = f(ys)
+				g = gcd(distance(x, ys), n)
+			}
+		}
+
+		if g != n {
+			return g
+		}
+	}
+
+	return 0
+}
+
+
+/* *** big integers *** **************************************************** */
+
+/* a non-trivial factor of the composite n or nil. maxIterations bounds the steps over all polynomials, 0 doesn't */
+func pollardRho(n *big.Int, maxIterations int) *big.Int {
+
+	if n.IsUint64() == true {
+		if f := pollardRhoSmallInt(n.Uint64()); f != 0 {
+			return big.NewInt(0).SetUint64(f)
+		}
+		return nil
+	}
+
+	if n.Bit(0) == 0 {
+		return big.NewInt(2)
+	}
+
+	x := big.NewInt(0)
+	y := big.NewInt(0)
+	ys := big.NewInt(0)
+	g := big.NewInt(0)
+	q := big.NewInt(0)
+	difference := big.NewInt(0)
+	C := big.NewInt(0)
+
+	iterations := 0
+
+	f := func(y *big.Int) {
+		y.Mul(y, y)
+		y.Add(y, C)
+		y.Mod(y, n)
+	}
+
+	for c := int64(1); c <= rhoAttempts; c += 1 {
+
+		C.SetInt64(c)
+		y.SetInt64(2)
+		g.SetInt64(1)
+		q.SetInt64(1)
+
+		for r := 1; g.Cmp(misc.One) == 0; r *= 2 {
+
+			if maxIterations > 0 && iterations >= maxIterations {
+				break
+			}
+
+			x.Set(y)
+			for i := 0; i < r; i += 1 {
+				f(y)
+			}
+
+			/* batch the gcds */
+			for k := 0; k < r && g.Cmp(misc.One) == 0; k += rhoBatch {
+				ys.Set(y)
+				for i := 0; i < rhoBatch && i < r - k; i += 1 {
+					f(y)
+					q.Mul(q, difference.Sub(x, y).Abs(difference))
+					q.Mod(q, n)
+				}
+				g.GCD(nil, nil, q, n)
+			}
+
+			iterations += 2*r
+		}
+
+		if g.Cmp(n) == 0 {
+			/* the batch overshot, step back one by one */
+			for g.SetInt64(1); g.Cmp(misc.One) == 0; {
+				f(ys)
+				g.GCD(nil, nil, difference.Sub(x, ys).Abs(difference), n)
+			}
+		}
+
+		if g.Cmp(misc.One) != 0 && g.Cmp(n) != 0 {
+			return g
+		}
+	}
+
+	return nil
+}
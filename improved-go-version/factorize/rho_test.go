@@ -0,0 +1,70 @@
+package main
+
+
+import (
+	"math/big"
+	"testing"
+)
+
+
+func TestPollardRhoSmallInt(t *testing.T) {
+
+	nums := []uint64{4, 15, 1000003 * 1000033, 2147483647 * 2147483629, 65537 * 4294967291, 4294967291 * 4294967279,
+			18446744073709551557 / 3 * 3, 3 * 3 * 3 * 21387273197}
+
+	for _, n := range nums {
+
+		f := pollardRhoSmallInt(n)
+
+		if f <= 1 || f >= n || n % f != 0 {
+			t.Error("pollardRhoSmallInt(", n, ") =", f, "is not a non-trivial factor")
+		}
+	}
+
+	for _, p := range []uint64{2, 3, 1000003} {
+		if f := pollardRhoSmallInt(p); f != 0 {
+			t.Error("pollardRhoSmallInt(", p, ") =", f, "for a prime")
+		}
+	}
+}
+
+
+func TestPollardRho(t *testing.T) {
+
+	/* 2^127 - 1 is prime */
+	mersenne := big.NewInt(0).Lsh(big.NewInt(1), 127)
+	mersenne.Sub(mersenne, big.NewInt(1))
+
+	nums := []*big.Int{
+			big.NewInt(2626849055875147),
+			big.NewInt(0).Mul(big.NewInt(1000003), mersenne),
+			big.NewInt(0).Mul(big.NewInt(1099511627791), mersenne), /* 2^40 + 15 */
+			}
+
+	rest := big.NewInt(0)
+
+	for _, n := range nums {
+
+		f := pollardRho(n, 0)
+
+		if f == nil || f.Cmp(big.NewInt(1)) <= 0 || f.Cmp(n) >= 0 || rest.Mod(n, f).Sign() != 0 {
+			t.Error("pollardRho(", n, ") =", f, "is not a non-trivial factor")
+		}
+
+		factors, err := factorCompletely(n, options{method: methodRho})
+		if err != nil {
+			t.Error(n, err)
+		} else {
+			testFactorization(t, n, factors)
+		}
+	}
+
+	/* 2^61 - 1 is prime, too. rho finds it only without a bound */
+	n := big.NewInt(0).Lsh(big.NewInt(1), 61)
+	n.Sub(n, big.NewInt(1))
+	n.Mul(n, mersenne)
+
+	if f := pollardRho(n, rhoPresieveIterations); f != nil {
+		t.Error("pollardRho(", n, ",", rhoPresieveIterations, ") found", f, "beyond its bound")
+	}
+}
@@ -0,0 +1,291 @@
+package main
+
+/* self-initializing quadratic sieve.
+ *
+ * A = q_1 * ... * q_s is a product of factor base primes. B^2 = n (mod A) has 2^s solutions
+ * B = +-B_1 +- ... +- B_s, half of them give distinct polynomials. they are enumerated in gray code
+ * order, so from one polynomial to the next only the sign of one B_v changes: B' = B + 2 e B_v. the
+ * sieve roots follow with x' = x - e * 2 B_v A^-1 (mod p), which is precomputed once per A. */
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"math/bits"
+	"math/rand"
+	"sort"
+
+	"github.com/hydroo/quadratic-sieve/misc"
+)
+
+
+/* give up after this many polynomials, there may not be enough smooth values for tiny n */
+const siqsMaxPolynomials = 1 << 18
+
+/* preferred size of the primes in A. smaller ones make A's factors hurt less, but need more of them */
+const siqsPreferredPrimeBits = 11
+
+
+func siqs(n *big.Int, factorBase []*big.Int, M int, wanted int, thresholdFudge float64, relations *relationSet) {
+
+	primes, threshold := polynomialSievePrimes(n, factorBase, M, thresholdFudge, relations.largePrimeBits())
+
+	/* A ~ sqrt(2n) / M */
+	targetBits := (float64(n.BitLen()) + 1) / 2 - math.Log2(float64(M))
+
+	candidates, s := siqsCandidates(n, primes, targetBits)
+	if len(candidates) < s {
+		return
+	}
+
+	rng := rand.New(rand.NewSource(1)) /* fixed seed, results are reproducible */
+
+	logs := make([]uint8, 2*M)
+	seenA := make(map[string]bool)
+
+	polynomials := 0
+
+	for relations.count() < wanted && polynomials < siqsMaxPolynomials {
+
+		aFactors := siqsChooseA(primes, candidates, s, targetBits, rng, seenA)
+		if aFactors == nil {
+			break
+		}
+
+		A, Bs := siqsAandBs(n, factorBase, primes, aFactors)
+
+		B := big.NewInt(0)
+		for _, Bj := range Bs {
+			B.Add(B, Bj)
+		}
+
+		soln, bainv2 := siqsInitialize(A, B, Bs, factorBase, primes)
+
+		signs := make([]int, len(Bs))
+		for j := range signs {
+			signs[j] = 1
+		}
+
+		twoBv := big.NewInt(0)
+
+		for i := 0; i < 1 << uint(len(Bs)-1) && relations.count() < wanted; i += 1 {
+
+			if i > 0 {
+				/* gray code: flip the sign of B_v. B_0's sign stays, -B gives the same polynomial */
+				v := bits.TrailingZeros(uint(i)) + 1
+
+				twoBv.Lsh(Bs[v], 1)
+
+				if signs[v] == 1 {
+					/* B' = B - 2 B_v -> x' = x + 2 B_v A^-1 */
+					B.Sub(B, twoBv)
+					siqsUpdateRoots(soln, bainv2, primes, v, 1)
+				} else {
+					B.Add(B, twoBv)
+					siqsUpdateRoots(soln, bainv2, primes, v, -1)
+				}
+
+				signs[v] = -signs[v]
+			}
+
+			starts := make([][]int64, len(primes))
+			for k := 1; k < len(primes); k += 1 {
+				p := primes[k].p
+				starts[k] = make([]int64, len(soln[k]))
+				for r, x := range soln[k] {
+					starts[k][r] = (x + int64(M)) % p
+				}
+			}
+
+			poly := polynomial{A, B, aFactors}
+
+			sievePolynomial(n, poly, factorBase, primes, starts, M, threshold, logs, relations)
+
+			polynomials += 1
+		}
+	}
+}
+
+
+/* factor base indizes eligible as factors of A and how many of them make up A */
+func siqsCandidates(n *big.Int, primes []sievePrime, targetBits float64) ([]int, int) {
+
+	maxBits := math.Log2(float64(primes[len(primes)-1].p))
+
+	qBits := math.Min(siqsPreferredPrimeBits, maxBits - 1)
+
+	s := int(math.Max(1.0, math.Floor(targetBits / qBits + 0.5)))
+
+	/* primes around the actual size targetBits / s. never 2 and no divisors of n, these have only one root */
+	candidates := []int{}
+	for width := 1.0; len(candidates) < s + 2 && width < maxBits; width += 1.0 {
+
+		candidates = candidates[:0]
+
+		for i := 1; i < len(primes); i += 1 {
+
+			bitsOfP := math.Log2(float64(primes[i].p))
+
+			if primes[i].p == 2 || len(primes[i].roots()) != 2 || math.Abs(bitsOfP - targetBits / float64(s)) > width {
+				continue
+			}
+
+			candidates = append(candidates, i)
+		}
+	}
+
+	return candidates, s
+}
+
+
+/* picks s - 1 random candidates and the last one such that A comes closest to 2^targetBits.
+returns nil if no new A could be found */
+func siqsChooseA(primes []sievePrime, candidates []int, s int, targetBits float64, rng *rand.Rand,
+		seenA map[string]bool) []int {
+
+	for attempts := 0; attempts < 100; attempts += 1 {
+
+		chosen := make(map[int]bool)
+		aFactors := make([]int, 0, s)
+		bitsOfA := 0.0
+
+		for len(aFactors) < s - 1 {
+			i := candidates[rng.Intn(len(candidates))]
+			if chosen[i] == true {
+				continue
+			}
+			chosen[i] = true
+			aFactors = append(aFactors, i)
+			bitsOfA += math.Log2(float64(primes[i].p))
+		}
+
+		best := -1
+		bestDistance := math.Inf(1)
+		for _, i := range candidates {
+			distance := math.Abs(bitsOfA + math.Log2(float64(primes[i].p)) - targetBits)
+			if chosen[i] == false && distance < bestDistance {
+				best = i
+				bestDistance = distance
+			}
+		}
+
+		if best == -1 {
+			continue
+		}
+
+		aFactors = append(aFactors, best)
+
+		sorted := make([]int, len(aFactors))
+		copy(sorted, aFactors)
+		sort.Ints(sorted)
+		key := fmt.Sprint(sorted)
+
+		if seenA[key] == true {
+			continue
+		}
+		seenA[key] = true
+
+		return aFactors
+	}
+
+	return nil
+}
+
+
+/* A = prod q_j and B_j = (A/q_j) * (sqrt(n) * (A/q_j)^-1 mod q_j). B_j^2 = n (mod q_j), B_j = 0 (mod q_i) for i != j */
+func siqsAandBs(n *big.Int, factorBase []*big.Int, primes []sievePrime, aFactors []int) (*big.Int, []*big.Int) {
+
+	A := big.NewInt(1)
+	for _, i := range aFactors {
+		A.Mul(A, factorBase[i])
+	}
+
+	Bs := make([]*big.Int, len(aFactors))
+
+	aOverQ := big.NewInt(0)
+	tmp := big.NewInt(0)
+
+	for j, i := range aFactors {
+
+		q := primes[i].p
+
+		aOverQ.Quo(A, factorBase[i])
+
+		gamma := misc.MulModSmallInt(primes[i].roots()[0],
+				misc.ModInverseSmallInt(tmp.Mod(aOverQ, factorBase[i]).Int64(), q), q)
+
+		if gamma > q / 2 {
+			gamma = q - gamma
+		}
+
+		Bs[j] = big.NewInt(0).Mul(aOverQ, big.NewInt(gamma))
+	}
+
+	B := big.NewInt(0)
+	for _, Bj := range Bs {
+		B.Add(B, Bj)
+	}
+
+	tmp.Mul(B, B)
+	tmp.Sub(tmp, n)
+	if tmp.Mod(tmp, A).Sign() != 0 {
+		panic("siqsAandBs(): B^2 != n (mod A)")
+	}
+
+	return A, Bs
+}
+
+
+/* roots x = A^-1 (r - B) (mod p) of the first polynomial and 2 B_j A^-1 (mod p), per prime.
+primes dividing A have no roots */
+func siqsInitialize(A, B *big.Int, Bs []*big.Int, factorBase []*big.Int, primes []sievePrime) ([][]int64, [][]int64) {
+
+	soln := make([][]int64, len(primes))
+	bainv2 := make([][]int64, len(primes))
+
+	tmp := big.NewInt(0)
+
+	for i := 1; i < len(primes); i += 1 {
+
+		p := primes[i].p
+
+		aModP := tmp.Mod(A, factorBase[i]).Int64()
+		if aModP == 0 {
+			soln[i] = []int64{}
+			continue
+		}
+
+		aInv := misc.ModInverseSmallInt(aModP, p)
+		bModP := tmp.Mod(B, factorBase[i]).Int64()
+
+		soln[i] = make([]int64, 0, 2)
+		for _, r := range primes[i].roots() {
+			soln[i] = append(soln[i], misc.MulModSmallInt((r - bModP + p) % p, aInv, p))
+		}
+
+		bainv2[i] = make([]int64, len(Bs))
+		for j, Bj := range Bs {
+			bainv2[i][j] = misc.MulModSmallInt(2 * tmp.Mod(Bj, factorBase[i]).Int64() % p, aInv, p)
+		}
+	}
+
+	return soln, bainv2
+}
+
+
+/* x' = x + sign * 2 B_v A^-1 (mod p) */
+func siqsUpdateRoots(soln, bainv2 [][]int64, primes []sievePrime, v int, sign int64) {
+
+	for i := 1; i < len(primes); i += 1 {
+
+		p := primes[i].p
+
+		for r, x := range soln[i] {
+			x = (x + sign * bainv2[i][v]) % p
+			if x < 0 {
+				x += p
+			}
+			soln[i][r] = x
+		}
+	}
+}
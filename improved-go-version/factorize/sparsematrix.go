@@ -0,0 +1,203 @@
+package main
+
+/* sparse matrix with coefficients of GF(2), stored as the row indizes of the ones per column.
+ *
+ * same orientation as the relation matrix from linearSystemFromExponents(): a row per factor base prime,
+ * a column per relation */
+
+import (
+	"fmt"
+	"math/bits"
+)
+
+
+/* *** SparseMatrix *** **************************************************** */
+type SparseMatrix struct {
+	columns [][]int /* sorted row indizes */
+	rowCount, columnCount int
+}
+
+
+func NewSparseMatrix(rows, columns int) *SparseMatrix {
+
+	if rows < 0 || columns < 0 {
+		panic(fmt.Sprint("columnCount ", columns, " < 0 or rowCount ", rows, " < 0 "))
+	}
+
+	var ret SparseMatrix
+	ret.rowCount = rows
+	ret.columnCount = columns
+	ret.columns = make([][]int, columns)
+
+	for i, _ := range ret.columns {
+		ret.columns[i] = []int{}
+	}
+
+	return &ret
+}
+
+
+/* the rows set to 1 in the column */
+func (this SparseMatrix) Column(index int) []int {
+	this.checkColumnIndex(index)
+	return this.columns[index]
+}
+
+
+/* rows have to be sorted */
+func (this *SparseMatrix) SetColumn(index int, rows []int) {
+	this.checkColumnIndex(index)
+
+	for i, row := range rows {
+		this.checkRowIndex(row)
+		if i > 0 && rows[i-1] >= row {
+			panic(fmt.Sprint("row indizes have to be sorted and unique ", rows))
+		}
+	}
+
+	this.columns[index] = rows
+}
+
+
+/* number of ones */
+func (this SparseMatrix) Weight() int {
+	ret := 0
+	for _, column := range this.columns {
+		ret += len(column)
+	}
+	return ret
+}
+
+
+/* B v. bit j of v[k] is row k of the j-th of 64 vectors */
+func (this SparseMatrix) Mul(v []uint64) []uint64 {
+
+	if len(v) != this.columnCount {
+		panic(fmt.Sprint("vector length ", len(v), " != columnCount ", this.columnCount))
+	}
+
+	ret := make([]uint64, this.rowCount)
+
+	for k, column := range this.columns {
+		for _, i := range column {
+			ret[i] ^= v[k]
+		}
+	}
+
+	return ret
+}
+
+
+/* B^T w */
+func (this SparseMatrix) MulTransposed(w []uint64) []uint64 {
+
+	if len(w) != this.rowCount {
+		panic(fmt.Sprint("vector length ", len(w), " != rowCount ", this.rowCount))
+	}
+
+	ret := make([]uint64, this.columnCount)
+
+	for k, column := range this.columns {
+		for _, i := range column {
+			ret[k] ^= w[i]
+		}
+	}
+
+	return ret
+}
+
+
+func (this SparseMatrix) Transpose() *SparseMatrix {
+
+	m := NewSparseMatrix(this.columnCount, this.rowCount)
+
+	/* columns are visited in order, so the new columns come out sorted */
+	for j, column := range this.columns {
+		for _, i := range column {
+			m.columns[i] = append(m.columns[i], j)
+		}
+	}
+
+	return m
+}
+
+
+func (this SparseMatrix) LinearSystem() *LinearSystem {
+
+	ret := NewLinearSystem(this.rowCount, this.columnCount)
+
+	for j, column := range this.columns {
+		for _, i := range column {
+			ret.Row(i).SetColumn(j, 1)
+		}
+	}
+
+	return ret
+}
+
+
+func SparseMatrixFromLinearSystem(ls *LinearSystem) *SparseMatrix {
+
+	ret := NewSparseMatrix(ls.rowCount, ls.columnCount)
+
+	/* rows are visited in order, so the columns come out sorted */
+	for i, row := range ls.rows {
+		for c, chunk := range row.chunks {
+			for ; chunk != 0; chunk &= chunk - 1 {
+				j := (len(row.chunks)-1-c)*64 + bits.TrailingZeros64(chunk)
+				ret.columns[j] = append(ret.columns[j], i)
+			}
+		}
+	}
+
+	return ret
+}
+
+
+func (this SparseMatrix) String() string {
+	return fmt.Sprint(this.LinearSystem())
+}
+
+
+func (this SparseMatrix) Equals(other *SparseMatrix) bool {
+
+	this.checkSameSize(other)
+
+	for j, column := range this.columns {
+
+		if len(column) != len(other.columns[j]) {
+			return false
+		}
+
+		for k, i := range column {
+			if i != other.columns[j][k] {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+
+/* *** private *** */
+func (this SparseMatrix) checkRowIndex(i int) {
+	if i < 0 || i >= this.rowCount {
+		panic(fmt.Sprint("invalid row index ", i, " is not element of [0 ,", this.rowCount,")"))
+	}
+}
+
+
+func (this SparseMatrix) checkColumnIndex(j int) {
+	if j < 0 || j >= this.columnCount {
+		panic(fmt.Sprint("invalid column index ", j, " is not element of [0 ,", this.columnCount,")"))
+	}
+}
+
+
+func (this SparseMatrix) checkSameSize(other *SparseMatrix) {
+	if this.rowCount != other.rowCount || this.columnCount != other.columnCount {
+		panic(fmt.Sprint("cannot perform operation on two sparse matrices of differing size. rowCount ",
+				this.rowCount, " != ", other.rowCount, " or columnCount ", this.columnCount, " != ", other.columnCount))
+	}
+}
@@ -0,0 +1,99 @@
+package main
+
+
+import (
+	"math/bits"
+	"math/rand"
+	"testing"
+)
+
+
+func TestSparseMatrixConversion(t *testing.T) {
+
+	rng := rand.New(rand.NewSource(1234))
+
+	for _, size := range []struct{ rows, columns int }{{1, 1}, {5, 70}, {130, 64}, {300, 333}} {
+
+		ls := NewLinearSystem(size.rows, size.columns)
+		for i := 0; i < size.rows; i += 1 {
+			for j := 0; j < size.columns; j += 1 {
+				if rng.Intn(10) == 0 {
+					ls.Row(i).SetColumn(j, 1)
+				}
+			}
+		}
+
+		m := SparseMatrixFromLinearSystem(ls)
+
+		if m.LinearSystem().Equals(ls) == false {
+			t.Error("conversion to a sparse matrix and back changed\n", ls, "\nto\n", m.LinearSystem())
+		}
+
+		if m.Transpose().LinearSystem().Equals(ls.Transpose()) == false {
+			t.Error("sparse and dense transposition differ for\n", ls)
+		}
+
+		if m.Transpose().Transpose().Equals(m) == false {
+			t.Error("transposing twice changed\n", m)
+		}
+	}
+}
+
+
+func TestSparseMatrixMul(t *testing.T) {
+
+	rng := rand.New(rand.NewSource(4321))
+
+	m := sparseMatrixFromExponents(randomSparseExponents(rng, 300, 350))
+	ls := m.LinearSystem()
+
+	v := make([]uint64, m.columnCount)
+	for k := range v {
+		v[k] = rng.Uint64()
+	}
+
+	w := make([]uint64, m.rowCount)
+	for k := range w {
+		w[k] = rng.Uint64()
+	}
+
+	/* row i of B v, bit by bit with the dense matrix */
+	bv := m.Mul(v)
+	for i := 0; i < m.rowCount; i += 1 {
+		var expect uint64
+		for j := 0; j < m.columnCount; j += 1 {
+			if ls.Row(i).Column(j) == 1 {
+				expect ^= v[j]
+			}
+		}
+		if bv[i] != expect {
+			t.Error("row", i, "of B v is", bv[i], "not", expect)
+		}
+	}
+
+	/* (B v)^T w = v^T (B^T w) */
+	if innerProductBlock(bv, w) != innerProductBlock(v, m.MulTransposed(w)) {
+		t.Error("(B v)^T w != v^T (B^T w)")
+	}
+
+	/* B^T w = (B^T) w */
+	btw := m.MulTransposed(w)
+	tbw := m.Transpose().Mul(w)
+	for k := range btw {
+		if btw[k] != tbw[k] {
+			t.Error("B^T w and the transposed matrix times w differ at", k)
+			break
+		}
+	}
+
+	weight := 0
+	for i := 0; i < m.rowCount; i += 1 {
+		for _, chunk := range ls.Row(i).chunks {
+			weight += bits.OnesCount64(chunk)
+		}
+	}
+
+	if m.Weight() != weight {
+		t.Error("weight", m.Weight(), "!=", weight)
+	}
+}
@@ -0,0 +1,285 @@
+package main
+
+/* factoring methods for n < 2^64 in native integers.
+ *
+ * shanks' square forms factorization (squfof) walks the continued fraction of sqrt(k n) until the denominator Q
+ * is a square at an even step, then walks the reduced form back to a symmetry point that gives a factor. it takes
+ * about n^(1/4) steps. some multipliers k fail, so several are tried. k n takes up to 75 bits and is kept in two
+ * words with math/bits, P and Q stay below 2 sqrt(k n) and fit into an int64.
+ *
+ * lehman's method finds p | n as gcd(a + b, n) from a^2 - 4 k n = b^2 for k <= n^(1/3) after trial division up
+ * to n^(1/3). hart's one line factoring is a simpler heuristic variant: s = ceil(sqrt(i n)) with s^2 mod n a
+ * square t^2 gives gcd(s - t, n). both take about n^(1/3) steps and need i n and 4 k n to fit into 64 bits, so
+ * they only take n < lehmanMax. */
+
+import (
+	"math"
+	"math/bits"
+
+	"github.com/hydroo/quadratic-sieve/misc"
+)
+
+
+/* lehman and hart for n below this, squfof above */
+const lehmanMax = 1 << 42
+
+/* products of small odd primes after gower and wagstaff */
+var squfofMultipliers = []uint64{1, 3, 5, 7, 11, 3*5, 3*7, 3*11, 5*7, 5*11, 7*11, 3*5*7, 3*5*11, 3*7*11, 5*7*11,
+		3*5*7*11}
+
+
+/* a non-trivial factor of the composite n and the name of the method that found it, 0 if none did.
+hart and lehman below lehmanMax, squfof above, pollard rho if those fail */
+func splitSmallInt(n uint64) (uint64, string) {
+
+	if n % 2 == 0 {
+		if n > 2 {
+			return 2, "trial"
+		}
+		return 0, ""
+	}
+
+	if r, ok := misc.IsSquareSmallInt(n); ok == true && r > 1 {
+		return r, "square"
+	}
+
+	if n < lehmanMax {
+		if f := hartSmallInt(n); f != 0 {
+			return f, "hart"
+		}
+		if f := lehmanSmallInt(n); f != 0 {
+			return f, "lehman"
+		}
+	} else if f := squfofSmallInt(n); f != 0 {
+		return f, "squfof"
+	}
+
+	return pollardRhoSmallInt(n), "rho"
+}
+
+
+/* a non-trivial factor of n or 0. n must be odd and not a square */
+func squfofSmallInt(n uint64) uint64 {
+
+	for _, k := range squfofMultipliers {
+		if f := squfofMultiplier(n, k); f != 0 {
+			return f
+		}
+	}
+
+	return 0
+}
+
+
+/* squfof on k n. 0 if it found nothing */
+func squfofMultiplier(n, k uint64) uint64 {
+
+	knHigh, knLow := bits.Mul64(k, n)
+
+	P0 := int64(squareRootFloorWideSmallInt(knHigh, knLow))
+
+	/* k n - P0^2 < 2 P0 + 1 */
+	high, low := bits.Mul64(uint64(P0), uint64(P0))
+	low, borrow := bits.Sub64(knLow, low, 0)
+	high, _ = bits.Sub64(knHigh, high, borrow)
+
+	if high == 0 && low == 0 {
+		/* k n is a square, n is not */
+		return nonTrivialFactorSmallInt(gcdSmallInt(n, uint64(P0)), n)
+	}
+
+	bound := 3 * 2 * int64(misc.SquareRootFloorSmallInt(2 * uint64(P0)))
+
+	/* forward to a square Q at an even step */
+	P, Pprevious := P0, P0
+	Q, Qprevious := int64(low), int64(1)
+	r := int64(0)
+
+	i := int64(2)
+	for ; i < bound; i += 1 {
+
+		b := (P0 + P) / Q
+		P = b * Q - P
+		q := Q
+		Q = Qprevious + b * (Pprevious - P)
+
+		if i % 2 == 0 {
+			if root, ok := misc.IsSquareSmallInt(uint64(Q)); ok == true {
+				r = int64(root)
+				break
+			}
+		}
+
+		Qprevious = q
+		Pprevious = P
+	}
+
+	if i >= bound {
+		return 0
+	}
+
+	/* back from the reduced form until P repeats */
+	b := (P0 - P) / r
+	P = b * r + P
+	Pprevious = P
+	Qprevious = r
+
+	/* (k n - P^2) / r, P <= P0 */
+	high, low = bits.Mul64(uint64(P), uint64(P))
+	low, borrow = bits.Sub64(knLow, low, 0)
+	high, _ = bits.Sub64(knHigh, high, borrow)
+
+	if high >= uint64(r) {
+		return 0
+	}
+
+	quotient, _ := bits.Div64(high, low, uint64(r))
+	Q = int64(quotient)
+
+	for i = 0; i < bound; i += 1 {
+
+		b = (P0 + P) / Q
+		Pprevious = P
+		P = b * Q - P
+		q := Q
+		Q = Qprevious + b * (Pprevious - P)
+		Qprevious = q
+
+		if P == Pprevious {
+			break
+		}
+	}
+
+	return nonTrivialFactorSmallInt(gcdSmallInt(n, uint64(Qprevious)), n)
+}
+
+
+/* floor(sqrt(high 2^64 + low)) */
+func squareRootFloorWideSmallInt(high, low uint64) uint64 {
+
+	/* the float is off by a few at most, also around 2^64 */
+	root := math.Sqrt(float64(high) * (1 << 64) + float64(low))
+
+	ret := uint64(1 << 64 - 1)
+	if root < 1 << 64 {
+		ret = uint64(root)
+	}
+
+	/* whether ret^2 > high 2^64 + low */
+	above := func(ret uint64) bool {
+		h, l := bits.Mul64(ret, ret)
+		return h > high || (h == high && l > low)
+	}
+
+	for above(ret) == true {
+		ret -= 1
+	}
+
+	for ret < 1 << 64 - 1 && above(ret + 1) == false {
+		ret += 1
+	}
+
+	return ret
+}
+
+
+/* a non-trivial factor of n or 0. n must be odd, not a square, below lehmanMax. heuristic, may find nothing */
+func hartSmallInt(n uint64) uint64 {
+
+	bound := cubeRootCeilSmallInt(n)
+
+	for i := uint64(1); i <= bound; i += 1 {
+
+		s := misc.SquareRootFloorSmallInt(i * n)
+		if s * s < i * n {
+			s += 1
+		}
+
+		if t, ok := misc.IsSquareSmallInt(s * s % n); ok == true {
+			if f := nonTrivialFactorSmallInt(gcdSmallInt(s - t, n), n); f != 0 {
+				return f
+			}
+		}
+	}
+
+	return 0
+}
+
+
+/* a non-trivial factor of n or 0 if n is prime. n must be odd, not a square, below lehmanMax */
+func lehmanSmallInt(n uint64) uint64 {
+
+	bound := cubeRootCeilSmallInt(n)
+
+	for _, p := range misc.SmallPrimes {
+		if uint64(p) > bound {
+			break
+		}
+		if n % uint64(p) == 0 && uint64(p) < n {
+			return uint64(p)
+		}
+	}
+
+	/* no prime up to n^(1/3), so at most two primes p <= q with a^2 - 4 k n = b^2 for some k <= n^(1/3) and
+	sqrt(4 k n) <= a <= sqrt(4 k n) + n^(1/6) / (4 sqrt(k)) */
+	sixthRoot := float64(misc.SquareRootFloorSmallInt(bound) + 1)
+
+	for k := uint64(1); k <= bound; k += 1 {
+
+		fourKN := 4 * k * n
+
+		a := misc.SquareRootFloorSmallInt(fourKN)
+		if a * a < fourKN {
+			a += 1
+		}
+
+		aMax := a + uint64(sixthRoot / (4 * float64(misc.SquareRootFloorSmallInt(k)))) + 1
+
+		for ; a <= aMax; a += 1 {
+			if b, ok := misc.IsSquareSmallInt(a * a - fourKN); ok == true {
+				if f := nonTrivialFactorSmallInt(gcdSmallInt(a + b, n), n); f != 0 {
+					return f
+				}
+			}
+		}
+	}
+
+	return 0
+}
+
+
+/* smallest c with c^3 >= n */
+func cubeRootCeilSmallInt(n uint64) uint64 {
+
+	/* the float is off by one at most */
+	c := uint64(math.Cbrt(float64(n)))
+
+	for c > 0 && (c - 1) * (c - 1) * (c - 1) >= n {
+		c -= 1
+	}
+
+	for c * c * c < n {
+		c += 1
+	}
+
+	return c
+}
+
+
+func gcdSmallInt(a, b uint64) uint64 {
+	for b != 0 {
+		a, b = b, a % b
+	}
+	return a
+}
+
+
+/* f if it is a non-trivial factor of n, 0 otherwise */
+func nonTrivialFactorSmallInt(f, n uint64) uint64 {
+
+	if f <= 1 || f >= n {
+		return 0
+	}
+
+	return f
+}
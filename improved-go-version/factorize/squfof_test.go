@@ -0,0 +1,95 @@
+package main
+
+
+import (
+	"math"
+	"math/big"
+	"math/rand"
+	"testing"
+)
+
+
+func TestSmallIntMethods(t *testing.T) {
+
+	/* odd composites, no squares */
+	nums := []uint64{15, 21, 101 * 103 * 107, 3 * 1000000007, 1000003 * 999983, 1000003 * 1000033,
+			3 * 3 * 3 * 21387273197, 122823 * 21387273197, 65537 * 4294967291, 1000003 * 4294967291,
+			2147483647 * 2147483629, 3 * 3 * 3 * 4294967291 * 65537, 4294967291 * 4294967279}
+
+	methods := []struct{
+		name string
+		f func(uint64) uint64
+		max uint64
+	}{
+		{"squfof", squfofSmallInt, math.MaxUint64},
+		{"hart", hartSmallInt, lehmanMax},
+		{"lehman", lehmanSmallInt, lehmanMax},
+	}
+
+	for _, n := range nums {
+
+		for _, method := range methods {
+
+			/* hart may find nothing */
+			if n >= method.max || method.name == "hart" {
+				continue
+			}
+
+			if f := method.f(n); f <= 1 || f >= n || n % f != 0 {
+				t.Error(method.name, "(", n, ") =", f, "is not a non-trivial factor")
+			}
+		}
+
+		if f, name := splitSmallInt(n); f <= 1 || f >= n || n % f != 0 {
+			t.Error("splitSmallInt(", n, ") =", f, "by", name, "is not a non-trivial factor")
+		}
+	}
+
+	for _, p := range []uint64{3, 1000003, 4294967291} {
+		if f := lehmanSmallInt(p); f != 0 {
+			t.Error("lehmanSmallInt(", p, ") =", f, "for a prime")
+		}
+	}
+}
+
+
+func TestSqufofBalanced(t *testing.T) {
+
+	rng := rand.New(rand.NewSource(1)) /* fixed seed, results are reproducible */
+
+	/* a random prime of the given number of bits */
+	prime := func(bitLength uint) uint64 {
+		for {
+			p := uint64(1) << (bitLength - 1) | rng.Uint64() >> (65 - bitLength) | 1
+			if big.NewInt(0).SetUint64(p).ProbablyPrime(20) == true {
+				return p
+			}
+		}
+	}
+
+	/* 60 to 64 bit n up to 2^64 */
+	for _, bitLengths := range [][2]uint{{30, 30}, {30, 31}, {31, 31}, {31, 32}, {32, 32}} {
+
+		failures := 0
+
+		for i := 0; i < 100; i += 1 {
+
+			p, q := prime(bitLengths[0]), prime(bitLengths[1])
+			if p == q {
+				continue
+			}
+
+			n := p * q
+
+			if f := squfofSmallInt(n); f == 0 {
+				failures += 1
+			} else if f != p && f != q {
+				t.Error("squfofSmallInt(", n, ") =", f, "is not", p, "or", q)
+			}
+		}
+
+		if failures > 0 {
+			t.Error("squfofSmallInt failed on", failures, "of 100", bitLengths[0], "by", bitLengths[1], "bit semiprimes")
+		}
+	}
+}
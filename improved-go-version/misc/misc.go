@@ -13,6 +13,14 @@ var One *big.Int
 var Two *big.Int
 var oneMillion *big.Int
 var firstFewPrimes []*big.Int
+var SmallPrimes []int64 /* the primes up to SmallPrimesMax */
+
+/* isSquareMod[i][r] if r is a square mod squareModuli[i]. together they let only about 1 in 100 non-squares through */
+var squareModuli = []uint64{64, 63, 65, 11}
+var isSquareMod [][]bool
+
+/* the product of squareModuli, n mod this is all IsSquareResidue() needs */
+const SquareResidueModulus = 64 * 63 * 65 * 11
 
 
 func init() {
@@ -22,6 +30,15 @@ func init() {
 	Two = big.NewInt(2)
 	oneMillion = big.NewInt(1000000)
 	firstFewPrimes = generateFirstFewPrimes()
+	SmallPrimes = Primes(SmallPrimesMax)
+
+	isSquareMod = make([][]bool, len(squareModuli))
+	for i, m := range squareModuli {
+		isSquareMod[i] = make([]bool, m)
+		for r := uint64(0); r < m; r += 1 {
+			isSquareMod[i][r * r % m] = true
+		}
+	}
 }
 
 
@@ -135,22 +152,12 @@ func isPrimeMillerRabin(n *big.Int) bool {
 
 func generateFirstFewPrimes() []*big.Int {
 
-	var primes [200000]*big.Int
-
-	primes[0] = big.NewInt(2)
-
-	count := 1
-	for n := int64(3); n <= 1000000; n += 2 {
-		if IsPrimeBruteForceSmallInt(n) == true {
-			primes[count] = big.NewInt(n)
-			count += 1
-		}
-	}
+	primes := PrimesUpTo(1000000)
 
-	bigPrimes := make([]*big.Int, count)
+	bigPrimes := make([]*big.Int, len(primes))
 
-	for i := 0; i < count; i += 1 {
-		bigPrimes[i] = primes[i]
+	for i, p := range primes {
+		bigPrimes[i] = big.NewInt(p)
 	}
 
 	return bigPrimes
@@ -203,3 +210,138 @@ func SquareRootCeil(n *big.Int) *big.Int {
 	return upperLimit
 }
 
+
+/* floor(sqrt(n)) */
+func SquareRootFloorSmallInt(n uint64) uint64 {
+
+	/* the float is off by a few at most, also around 2^64 */
+	ret := uint64(math.Sqrt(float64(n)))
+	if ret > 1 << 32 - 1 {
+		ret = 1 << 32 - 1
+	}
+
+	for ret * ret > n {
+		ret -= 1
+	}
+
+	for ret < 1 << 32 - 1 && (ret + 1) * (ret + 1) <= n {
+		ret += 1
+	}
+
+	return ret
+}
+
+
+/* false if n with n = r (mod SquareResidueModulus) is certainly no square */
+func IsSquareResidue(r uint64) bool {
+
+	for i, m := range squareModuli {
+		if isSquareMod[i][r % m] == false {
+			return false
+		}
+	}
+
+	return true
+}
+
+
+/* sqrt(n), true if n is a square. residues mod a few small numbers rule out most non-squares cheaply */
+func IsSquareSmallInt(n uint64) (uint64, bool) {
+
+	if IsSquareResidue(n) == false {
+		return 0, false
+	}
+
+	r := SquareRootFloorSmallInt(n)
+
+	return r, r * r == n
+}
+
+
+/* sqrt(n), true if n >= 0 is a square. like IsSquareSmallInt() */
+func IsSquare(n *big.Int) (*big.Int, bool) {
+
+	r := big.NewInt(0)
+
+	if n.Sign() < 0 || IsSquareResidue(r.Mod(n, big.NewInt(SquareResidueModulus)).Uint64()) == false {
+		return nil, false
+	}
+
+	r.Sqrt(n)
+	square := big.NewInt(0).Mul(r, r)
+
+	if square.Cmp(n) != 0 {
+		return nil, false
+	}
+
+	return r, true
+}
+
+
+/* floor(n^(1/k)) for n >= 0, k >= 1 (newton) */
+func RootFloor(n *big.Int, k int) *big.Int {
+
+	if n.Sign() < 0 || k < 1 {
+		panic("RootFloor(): n < 0 or k < 1")
+	}
+
+	if n.Sign() == 0 || k == 1 {
+		return big.NewInt(0).Set(n)
+	}
+
+	/* start above the root: 2^ceil(bits / k) */
+	x := big.NewInt(0)
+	x.Lsh(One, uint((n.BitLen() + k - 1) / k))
+
+	K := big.NewInt(int64(k))
+	KMinusOne := big.NewInt(int64(k - 1))
+	y := big.NewInt(0)
+	power := big.NewInt(0)
+
+	for {
+		/* y = ((k - 1) x + n / x^(k-1)) / k */
+		power.Exp(x, KMinusOne, nil)
+		y.Quo(n, power)
+		power.Mul(x, KMinusOne)
+		y.Add(y, power)
+		y.Quo(y, K)
+
+		if y.Cmp(x) >= 0 {
+			return x
+		}
+
+		x.Set(y)
+	}
+}
+
+
+/* n = base^k with the largest such k. n, 1 if n is no perfect power */
+func PerfectPower(n *big.Int) (*big.Int, int) {
+
+	if n.Cmp(Two) < 0 {
+		return big.NewInt(0).Set(n), 1
+	}
+
+	base := big.NewInt(0).Set(n)
+	exponent := 1
+	power := big.NewInt(0)
+
+	/* if base = r^(k l) it is found as (r^l)^k first and then again as r^l. only prime k have to be tried */
+	for _, k := range SmallPrimes {
+
+		if int(k) >= base.BitLen() {
+			break
+		}
+
+		for {
+			root := RootFloor(base, int(k))
+			if power.Exp(root, big.NewInt(k), nil).Cmp(base) != 0 {
+				break
+			}
+			base = root
+			exponent *= int(k)
+		}
+	}
+
+	return base, exponent
+}
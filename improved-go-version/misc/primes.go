@@ -0,0 +1,83 @@
+package misc
+
+/* prime tables from the sieve of eratosthenes */
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+
+/* bound for the SmallPrimes table, e.g. for trial division */
+const SmallPrimesMax = 1 << 16
+
+
+/* the primes up to max. only odd numbers are sieved */
+func PrimesUpTo(max int64) []int64 {
+
+	if max < 2 {
+		return []int64{}
+	}
+
+	if max > 1 << 40 {
+		panic(fmt.Sprint("PrimesUpTo(): ", max, " is too large to sieve"))
+	}
+
+	/* composite[i] for 2i + 3 */
+	composite := make([]bool, (max - 1) / 2)
+
+	for i := int64(0); i < int64(len(composite)); i += 1 {
+
+		if composite[i] == true {
+			continue
+		}
+
+		p := 2*i + 3
+		if p * p > max {
+			break
+		}
+
+		for j := (p * p - 3) / 2; j < int64(len(composite)); j += p {
+			composite[j] = true
+		}
+	}
+
+	ret := []int64{2}
+
+	for i, c := range composite {
+		if c == false {
+			ret = append(ret, int64(2*i + 3))
+		}
+	}
+
+	return ret
+}
+
+
+/* the table behind Primes() */
+var primeTable []int64
+var primeTableMax int64
+var primeTableLock sync.Mutex
+
+
+/* the primes up to max, from a table shared by all callers. it grows on demand, the result must not be modified */
+func Primes(max int64) []int64 {
+
+	primeTableLock.Lock()
+	defer primeTableLock.Unlock()
+
+	if max > primeTableMax {
+		/* at least double it, so growing it step by step stays cheap */
+		size := max
+		if size < 2*primeTableMax {
+			size = 2*primeTableMax
+		}
+		primeTable = PrimesUpTo(size)
+		primeTableMax = size
+	}
+
+	count := sort.Search(len(primeTable), func(i int) bool { return primeTable[i] > max })
+
+	return primeTable[:count:count]
+}
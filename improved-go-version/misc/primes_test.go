@@ -0,0 +1,156 @@
+package misc
+
+
+import (
+	"math/big"
+	"testing"
+)
+
+
+func TestPrimesUpTo(t *testing.T) {
+
+	for _, max := range []int64{-1, 0, 1, 2, 3, 4, 100, 7919, 100000} {
+
+		primes := PrimesUpTo(max)
+
+		count := 0
+		for n := int64(2); n <= max; n += 1 {
+			if IsPrimeBruteForceSmallInt(n) == true {
+				if count >= len(primes) || primes[count] != n {
+					t.Error("PrimesUpTo(", max, ") misses", n)
+					break
+				}
+				count += 1
+			}
+		}
+
+		if count != len(primes) {
+			t.Error("PrimesUpTo(", max, ") has", len(primes), "primes instead of", count)
+		}
+	}
+}
+
+
+func TestPerfectPower(t *testing.T) {
+
+	type Power struct {
+		base string
+		exponent int
+	}
+
+	powers := []Power{{"2", 1}, {"2", 64}, {"3", 40}, {"6", 6}, {"10", 30}, {"65537", 3}, {"4294967291", 7},
+			{"1000000007", 1}, {"340282366920938463463374607431768211297", 2}}
+
+	for _, power := range powers {
+
+		b, _ := big.NewInt(0).SetString(power.base, 10)
+		n := big.NewInt(0).Exp(b, big.NewInt(int64(power.exponent)), nil)
+
+		base, exponent := PerfectPower(n)
+
+		if base.Cmp(b) != 0 || exponent != power.exponent {
+			t.Error(n, "=", b, "^", power.exponent, "but PerfectPower() found", base, "^", exponent)
+		}
+
+		/* one more and it is no perfect power anymore */
+		if power.exponent > 1 {
+			n.Add(n, One)
+			if base, exponent := PerfectPower(n); exponent != 1 || base.Cmp(n) != 0 {
+				t.Error(n, "is no perfect power but PerfectPower() found", base, "^", exponent)
+			}
+		}
+	}
+
+	for n := int64(0); n < 2000; n += 1 {
+		for k := 1; k < 12; k += 1 {
+			r := RootFloor(big.NewInt(n), k).Int64()
+			if pow(r, k) > n || pow(r + 1, k) <= n {
+				t.Error("RootFloor(", n, ",", k, ") =", r)
+			}
+		}
+	}
+}
+
+
+func pow(b int64, k int) int64 {
+	ret := int64(1)
+	for i := 0; i < k; i += 1 {
+		ret *= b
+	}
+	return ret
+}
+
+
+func TestIsSquareSmallInt(t *testing.T) {
+
+	for _, r := range []uint64{0, 1, 2, 3, 1000, 65535, 65536, 3037000499, 4294967295} {
+
+		if root, ok := IsSquareSmallInt(r * r); ok == false || root != r {
+			t.Error(r * r, "=", r, "^ 2 but IsSquareSmallInt() found", root, ok)
+		}
+
+		if r > 0 {
+			if _, ok := IsSquareSmallInt(r * r + 1); ok == true {
+				t.Error(r * r + 1, "is no square")
+			}
+		}
+
+		if r > 1 {
+			if _, ok := IsSquareSmallInt(r * r - 1); ok == true {
+				t.Error(r * r - 1, "is no square")
+			}
+		}
+	}
+
+	if root := SquareRootFloorSmallInt(1 << 64 - 1); root != 1 << 32 - 1 {
+		t.Error("floor(sqrt(2^64 - 1)) =", root)
+	}
+
+	/* against the definition */
+	for n := uint64(0); n < 10000; n += 1 {
+		r := SquareRootFloorSmallInt(n)
+		if r * r > n || (r + 1) * (r + 1) <= n {
+			t.Error("floor(sqrt(", n, ")) =", r)
+		}
+		if _, ok := IsSquareSmallInt(n); ok != (r * r == n) {
+			t.Error("IsSquareSmallInt(", n, ") =", ok)
+		}
+	}
+}
+
+
+func TestIsSquare(t *testing.T) {
+
+	r, _ := big.NewInt(0).SetString("340282366920938463463374607431768211297", 10)
+
+	for _, root := range []*big.Int{big.NewInt(0), big.NewInt(1), big.NewInt(4294967295), r} {
+
+		n := big.NewInt(0).Mul(root, root)
+
+		if s, ok := IsSquare(n); ok == false || s.Cmp(root) != 0 {
+			t.Error(n, "=", root, "^ 2 but IsSquare() found", s, ok)
+		}
+
+		if _, ok := IsSquare(n.Add(n, Two)); ok == true {
+			t.Error(n, "is no square")
+		}
+	}
+
+	if _, ok := IsSquare(big.NewInt(-4)); ok == true {
+		t.Error("-4 is no square")
+	}
+}
+
+
+func TestPrimes(t *testing.T) {
+
+	for _, max := range []int64{100, 10, 100000, 7919, 7918, 1 << 16} {
+
+		primes := Primes(max)
+		should := PrimesUpTo(max)
+
+		if len(primes) != len(should) || primes[len(primes)-1] != should[len(should)-1] {
+			t.Error("Primes(", max, ") has", len(primes), "primes up to", primes[len(primes)-1], "instead of", len(should))
+		}
+	}
+}
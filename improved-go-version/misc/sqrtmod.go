@@ -0,0 +1,484 @@
+package misc
+
+/* square roots modulo primes and prime powers.
+ *
+ * every routine comes twice: a fast path for native integers (...SmallInt) and a *big.Int path.
+ * tonelli-shanks is used, unless p - 1 is divisible by a large power of two. tonelli-shanks needs
+ * O(s^2) multiplications for p - 1 = q * 2^s, in that case cipolla with O(log p) is faster. */
+
+import (
+	"math/big"
+	"math/bits"
+)
+
+
+/* *** native integers *** ************************************************* */
+
+/* a * b mod m without overflow for any m < 2^63 */
+func MulModSmallInt(a, b, m int64) int64 {
+	hi, lo := bits.Mul64(uint64(a), uint64(b))
+	return int64(bits.Rem64(hi, lo, uint64(m)))
+}
+
+
+/* base^exp mod m. base >= 0, exp >= 0, 0 < m < 2^63 */
+func PowModSmallInt(base, exp, m int64) int64 {
+
+	ret := int64(1) % m
+	base %= m
+
+	for ; exp > 0; exp >>= 1 {
+		if exp & 1 == 1 {
+			ret = MulModSmallInt(ret, base, m)
+		}
+		base = MulModSmallInt(base, base, m)
+	}
+
+	return ret
+}
+
+
+/* a^-1 mod m. returns -1 if gcd(a, m) != 1 */
+func ModInverseSmallInt(a, m int64) int64 {
+
+	a %= m
+	if a < 0 {
+		a += m
+	}
+
+	oldR, r := a, m
+	oldS, s := int64(1), int64(0)
+
+	for r != 0 {
+		quotient := oldR / r
+		oldR, r = r, oldR - quotient*r
+		oldS, s = s, oldS - quotient*s
+	}
+
+	if oldR != 1 {
+		return -1
+	}
+
+	if oldS < 0 {
+		oldS += m
+	}
+
+	return oldS
+}
+
+
+/* legendre symbol (a/p) for an odd prime p: 1, -1 or 0 if p | a */
+func LegendreSmallInt(a, p int64) int {
+
+	a %= p
+	if a < 0 {
+		a += p
+	}
+
+	if a == 0 {
+		return 0
+	}
+
+	if PowModSmallInt(a, (p-1)/2, p) == 1 {
+		return 1
+	}
+
+	return -1
+}
+
+
+/* one solution r of r^2 = a (mod p) for a prime p, the other one is p - r.
+ * returns -1 if a is not a square rest mod p */
+func SquareRootModPrimeSmallInt(a, p int64) int64 {
+
+	a %= p
+	if a < 0 {
+		a += p
+	}
+
+	if a == 0 || p == 2 {
+		return a
+	}
+
+	if LegendreSmallInt(a, p) != 1 {
+		return -1
+	}
+
+	if p % 4 == 3 {
+		return PowModSmallInt(a, (p+1)/4, p)
+	}
+
+	/* p - 1 = q * 2^s */
+	s := bits.TrailingZeros64(uint64(p - 1))
+	q := (p - 1) >> uint(s)
+
+	if useCipolla(s, bits.Len64(uint64(p))) {
+		return cipollaSmallInt(a, p)
+	}
+
+	/* any quadratic non-residue z */
+	z := int64(2)
+	for LegendreSmallInt(z, p) != -1 {
+		z += 1
+	}
+
+	m := s
+	c := PowModSmallInt(z, q, p)
+	t := PowModSmallInt(a, q, p)
+	r := PowModSmallInt(a, (q+1)/2, p)
+
+	for t != 1 {
+
+		/* smallest i with t^(2^i) = 1 */
+		i := 0
+		for tt := t; tt != 1; tt = MulModSmallInt(tt, tt, p) {
+			i += 1
+		}
+
+		b := c
+		for j := 0; j < m - i - 1; j += 1 {
+			b = MulModSmallInt(b, b, p)
+		}
+
+		m = i
+		c = MulModSmallInt(b, b, p)
+		t = MulModSmallInt(t, c, p)
+		r = MulModSmallInt(r, b, p)
+	}
+
+	return r
+}
+
+
+/* cipolla: with w = t^2 - a a non-residue, (t + sqrt(w))^((p+1)/2) is a square root of a in F_p[sqrt(w)] */
+func cipollaSmallInt(a, p int64) int64 {
+
+	t := int64(0)
+	w := int64(0)
+	for t = 1; ; t += 1 {
+		w = (MulModSmallInt(t, t, p) - a + p) % p
+		if LegendreSmallInt(w, p) == -1 {
+			break
+		}
+	}
+
+	/* (x0 + x1 sqrt(w)) * (y0 + y1 sqrt(w)) = (x0 y0 + x1 y1 w) + (x0 y1 + x1 y0) sqrt(w) */
+	mul := func(x0, x1, y0, y1 int64) (int64, int64) {
+		r0 := (MulModSmallInt(x0, y0, p) + MulModSmallInt(MulModSmallInt(x1, y1, p), w, p)) % p
+		r1 := (MulModSmallInt(x0, y1, p) + MulModSmallInt(x1, y0, p)) % p
+		return r0, r1
+	}
+
+	r0, r1 := int64(1), int64(0)
+	b0, b1 := t, int64(1)
+
+	for exp := (p + 1) / 2; exp > 0; exp >>= 1 {
+		if exp & 1 == 1 {
+			r0, r1 = mul(r0, r1, b0, b1)
+		}
+		b0, b1 = mul(b0, b1, b0, b1)
+	}
+
+	return r0
+}
+
+
+/* all solutions of x^2 = a (mod q), given all solutions mod q/p in roots. q < 2^62.
+ * the result is empty if there are none */
+func LiftSquareRootsSmallInt(a, p int64, roots []int64, q int64) []int64 {
+
+	lowerQ := q / p
+
+	a %= q
+	if a < 0 {
+		a += q
+	}
+
+	ret := make([]int64, 0, len(roots))
+
+	if p != 2 && a % p != 0 {
+
+		/* hensel: r' = r - (r^2 - a) / (2r) (mod q) */
+		for _, r := range roots {
+			f := (MulModSmallInt(r, r, q) - a + q) % q
+			r = (r - MulModSmallInt(f, ModInverseSmallInt(2*r, q), q) + q) % q
+			ret = append(ret, r)
+		}
+
+	} else {
+
+		/* p = 2 and p | a have irregular solutions, but p is small or rare -> try all p lifts */
+		for _, r := range roots {
+			for x := r; x < q; x += lowerQ {
+				if MulModSmallInt(x, x, q) == a {
+					ret = append(ret, x)
+				}
+			}
+		}
+	}
+
+	return ret
+}
+
+
+/* all solutions of x^2 = a (mod p^k), sorted in no particular order. p^k < 2^62 */
+func SquareRootsModPrimePowerSmallInt(a, p int64, k int) []int64 {
+
+	r := SquareRootModPrimeSmallInt(a, p)
+
+	if r == -1 {
+		return []int64{}
+	}
+
+	roots := []int64{r}
+	if r != p - r && r != 0 {
+		roots = append(roots, p - r)
+	}
+
+	q := p
+	for i := 1; i < k && len(roots) > 0; i += 1 {
+		q *= p
+		roots = LiftSquareRootsSmallInt(a, p, roots, q)
+	}
+
+	return roots
+}
+
+
+/* *** big integers *** **************************************************** */
+
+/* legendre symbol (a/p) for an odd prime p: 1, -1 or 0 if p | a */
+func Legendre(a, p *big.Int) int {
+	A := big.NewInt(0)
+	A.Mod(a, p)
+	return big.Jacobi(A, p)
+}
+
+
+/* one solution r of r^2 = a (mod p) for a prime p, the other one is p - r.
+ * returns nil if a is not a square rest mod p */
+func SquareRootModPrime(a, p *big.Int) *big.Int {
+
+	if p.BitLen() < 63 {
+		r := SquareRootModPrimeSmallInt(big.NewInt(0).Mod(a, p).Int64(), p.Int64())
+		if r == -1 {
+			return nil
+		}
+		return big.NewInt(r)
+	}
+
+	A := big.NewInt(0)
+	A.Mod(a, p)
+
+	if A.Sign() == 0 {
+		return A
+	}
+
+	if big.Jacobi(A, p) != 1 {
+		return nil
+	}
+
+	exp := big.NewInt(0)
+
+	if p.Bit(1) == 1 {
+		/* p = 3 (mod 4) */
+		exp.Add(p, One)
+		exp.Rsh(exp, 2)
+		return exp.Exp(A, exp, p)
+	}
+
+	/* p - 1 = q * 2^s */
+	pMinusOne := big.NewInt(0)
+	pMinusOne.Sub(p, One)
+	s := int(pMinusOne.TrailingZeroBits())
+	q := big.NewInt(0)
+	q.Rsh(pMinusOne, uint(s))
+
+	if useCipolla(s, p.BitLen()) {
+		return cipolla(A, p)
+	}
+
+	z := big.NewInt(2)
+	for big.Jacobi(z, p) != -1 {
+		z.Add(z, One)
+	}
+
+	m := s
+	c := big.NewInt(0).Exp(z, q, p)
+	t := big.NewInt(0).Exp(A, q, p)
+	exp.Add(q, One)
+	exp.Rsh(exp, 1)
+	r := big.NewInt(0).Exp(A, exp, p)
+
+	tt := big.NewInt(0)
+	b := big.NewInt(0)
+
+	for t.Cmp(One) != 0 {
+
+		i := 0
+		for tt.Set(t); tt.Cmp(One) != 0; tt.Mod(tt.Mul(tt, tt), p) {
+			i += 1
+		}
+
+		b.Set(c)
+		for j := 0; j < m - i - 1; j += 1 {
+			b.Mod(b.Mul(b, b), p)
+		}
+
+		m = i
+		c.Mod(c.Mul(b, b), p)
+		t.Mod(t.Mul(t, c), p)
+		r.Mod(r.Mul(r, b), p)
+	}
+
+	return r
+}
+
+
+func cipolla(a, p *big.Int) *big.Int {
+
+	t := big.NewInt(1)
+	w := big.NewInt(0)
+	for ; ; t.Add(t, One) {
+		w.Mul(t, t)
+		w.Sub(w, a)
+		w.Mod(w, p)
+		if big.Jacobi(w, p) == -1 {
+			break
+		}
+	}
+
+	tmp := big.NewInt(0)
+
+	/* (x0 + x1 sqrt(w)) * (y0 + y1 sqrt(w)), result goes into x */
+	mul := func(x0, x1, y0, y1 *big.Int) {
+		r0 := big.NewInt(0).Mul(x0, y0)
+		tmp.Mul(x1, y1)
+		tmp.Mul(tmp, w)
+		r0.Add(r0, tmp)
+		r0.Mod(r0, p)
+
+		r1 := big.NewInt(0).Mul(x0, y1)
+		tmp.Mul(x1, y0)
+		r1.Add(r1, tmp)
+		r1.Mod(r1, p)
+
+		x0.Set(r0)
+		x1.Set(r1)
+	}
+
+	r0, r1 := big.NewInt(1), big.NewInt(0)
+	b0, b1 := big.NewInt(0).Set(t), big.NewInt(1)
+
+	exp := big.NewInt(0)
+	exp.Add(p, One)
+	exp.Rsh(exp, 1)
+
+	for i := 0; i < exp.BitLen(); i += 1 {
+		if exp.Bit(i) == 1 {
+			mul(r0, r1, b0, b1)
+		}
+		mul(b0, b1, big.NewInt(0).Set(b0), big.NewInt(0).Set(b1))
+	}
+
+	return r0
+}
+
+
+/* all solutions of x^2 = a (mod p^k) for a prime p with gcd(a, p) = 1.
+ * returns an empty slice if there are none */
+func SquareRootsModPrimePower(a, p *big.Int, k int) []*big.Int {
+
+	q := big.NewInt(0)
+	q.Exp(p, big.NewInt(int64(k)), nil)
+
+	A := big.NewInt(0)
+	A.Mod(a, q)
+
+	if big.NewInt(0).Mod(A, p).Sign() == 0 {
+		panic("SquareRootsModPrimePower(): a has to be coprime to p")
+	}
+
+	if p.Cmp(Two) == 0 {
+		return squareRootsModPowerOfTwo(A, k)
+	}
+
+	r := SquareRootModPrime(A, p)
+
+	if r == nil {
+		return []*big.Int{}
+	}
+
+	/* hensel: r' = r - (r^2 - a) / (2r) (mod p^i) */
+	pi := big.NewInt(0).Set(p)
+	f := big.NewInt(0)
+	inv := big.NewInt(0)
+	for i := 1; i < k; i += 1 {
+		pi.Mul(pi, p)
+		f.Mul(r, r)
+		f.Sub(f, A)
+		inv.Lsh(r, 1)
+		inv.ModInverse(inv, pi)
+		f.Mul(f, inv)
+		r.Sub(r, f)
+		r.Mod(r, pi)
+	}
+
+	minusR := big.NewInt(0)
+	minusR.Sub(q, r)
+
+	return []*big.Int{r, minusR}
+}
+
+
+/* odd a: x^2 = a (mod 2^k) has 1, 2 or 4 solutions or none */
+func squareRootsModPowerOfTwo(a *big.Int, k int) []*big.Int {
+
+	q := big.NewInt(0).Lsh(One, uint(k))
+
+	if k == 1 {
+		return []*big.Int{big.NewInt(1)}
+	}
+
+	if k == 2 {
+		if a.Bit(1) == 1 {
+			return []*big.Int{}
+		}
+		return []*big.Int{big.NewInt(1), big.NewInt(3)}
+	}
+
+	if a.Uint64() & 7 != 1 {
+		return []*big.Int{}
+	}
+
+	/* if x^2 = a (mod 2^i), either x or x + 2^(i-1) is a solution mod 2^(i+1) */
+	x := big.NewInt(1)
+	x2 := big.NewInt(0)
+	mod := big.NewInt(0)
+	for i := 3; i < k; i += 1 {
+		mod.Lsh(One, uint(i+1))
+		x2.Mul(x, x)
+		x2.Sub(x2, a)
+		x2.Mod(x2, mod)
+		if x2.Sign() != 0 {
+			x.Add(x, big.NewInt(0).Lsh(One, uint(i-1)))
+		}
+	}
+
+	half := big.NewInt(0).Rsh(q, 1)
+
+	ret := make([]*big.Int, 4)
+	ret[0] = x
+	ret[1] = big.NewInt(0).Sub(q, x)
+	ret[2] = big.NewInt(0).Add(x, half)
+	ret[2].Mod(ret[2], q)
+	ret[3] = big.NewInt(0).Sub(q, ret[2])
+
+	return ret
+}
+
+
+/* tonelli-shanks does O(s^2) multiplications, cipolla about 2 log2(p) in F_p^2 at four each */
+func useCipolla(s, bitLen int) bool {
+	return s*s > 8*bitLen + 20
+}
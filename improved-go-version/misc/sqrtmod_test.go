@@ -0,0 +1,123 @@
+package misc
+
+
+import (
+	"math/big"
+	"testing"
+)
+
+
+func TestSquareRootModPrimeSmallInt(t *testing.T) {
+
+	/* 7340033 = 7 * 2^20 + 1 and 998244353 = 119 * 2^23 + 1 go through cipolla */
+	primes := []int64{2, 3, 5, 7, 13, 17, 97, 257, 65537, 7340033, 998244353, 2305843009213693951}
+
+	for _, p := range primes {
+		for a := int64(0); a < 200; a += 1 {
+
+			r := SquareRootModPrimeSmallInt(a, p)
+			aModP := a % p
+
+			isSquare := p == 2 || aModP == 0 || LegendreSmallInt(a, p) == 1
+
+			if r == -1 {
+				if isSquare == true {
+					t.Error(a, "is a square mod", p, "but no root was found")
+				}
+				continue
+			}
+
+			if MulModSmallInt(r, r, p) != aModP {
+				t.Error(r, "^2 != ", a, "mod", p)
+			}
+		}
+	}
+}
+
+
+func TestSquareRootModPrime(t *testing.T) {
+
+	/* 2^127 - 1 = 3 (mod 4), 25 * 2^64 + 1 goes through cipolla */
+	p1 := big.NewInt(0).Sub(big.NewInt(0).Lsh(One, 127), One)
+	p3 := big.NewInt(0).Add(big.NewInt(0).Lsh(big.NewInt(25), 64), One)
+
+	for _, p := range []*big.Int{p1, p3} {
+		if p.ProbablyPrime(20) == false {
+			t.Fatal(p, "is supposed to be prime")
+		}
+
+		for a := int64(1); a < 100; a += 1 {
+			A := big.NewInt(a)
+			r := SquareRootModPrime(A, p)
+
+			if (r == nil) != (Legendre(A, p) == -1) {
+				t.Error("root", r, "of", a, "mod", p, "doesn't match the legendre symbol")
+				continue
+			}
+
+			if r != nil && big.NewInt(0).Exp(r, Two, p).Cmp(A) != 0 {
+				t.Error(r, "^2 !=", a, "mod", p)
+			}
+		}
+	}
+}
+
+
+func TestSquareRootsModPrimePower(t *testing.T) {
+
+	type Test struct {
+		a, p int64
+		k int
+		count int
+	}
+
+	tests := []Test{
+		{1, 2, 1, 1},
+		{1, 2, 2, 2},
+		{3, 2, 2, 0},
+		{17, 2, 10, 4},
+		{5, 2, 10, 0},
+		{2, 7, 5, 2},
+		{3, 7, 5, 0},
+		{10, 13, 4, 2},
+		{0, 3, 4, 9},  /* x = 0, 9, ..., 72 (mod 81) */
+		{9, 3, 3, 6},  /* x = 3, 6, 12, 15, 21, 24 (mod 27) */
+	}
+
+	for _, test := range tests {
+
+		q := int64(1)
+		for i := 0; i < test.k; i += 1 {
+			q *= test.p
+		}
+
+		roots := SquareRootsModPrimePowerSmallInt(test.a, test.p, test.k)
+
+		if len(roots) != test.count {
+			t.Error("x^2 =", test.a, "mod", test.p, "^", test.k, "should have", test.count, "solutions, not", roots)
+		}
+
+		for _, r := range roots {
+			if r*r % q != test.a % q {
+				t.Error(r, "^2 !=", test.a, "mod", q)
+			}
+		}
+
+		if test.a % test.p == 0 {
+			continue
+		}
+
+		bigRoots := SquareRootsModPrimePower(big.NewInt(test.a), big.NewInt(test.p), test.k)
+
+		if len(bigRoots) != test.count {
+			t.Error("x^2 =", test.a, "mod", test.p, "^", test.k, "has", test.count, "solutions, not", bigRoots)
+		}
+
+		Q := big.NewInt(q)
+		for _, r := range bigRoots {
+			if big.NewInt(0).Exp(r, Two, Q).Int64() != test.a % q {
+				t.Error(r, "^2 !=", test.a, "mod", q)
+			}
+		}
+	}
+}